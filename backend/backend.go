@@ -12,6 +12,12 @@ type Backend interface {
 	String() string
 }
 
+// Prioritized is implemented by backends that support the WithPriority option.
+// Backends that do not implement it have a priority of 0.
+type Prioritized interface {
+	Priority() int
+}
+
 type Content struct {
 	Data      encoder.Data
 	Encoder   encoder.Encoder
@@ -21,5 +27,44 @@ type Content struct {
 
 type Watcher interface {
 	Watch() <-chan *Content
-	Stop()
+	// Stop stops watching and waits until the goroutines started by Watch
+	// exit. Calling it more than once is allowed.
+	Stop() error
+}
+
+// ErrorWatcher is implemented by watchers that report the errors they hit
+// while watching, for example a config file that can not be read or parsed.
+// The channel is buffered, errors that do not fit are dropped, so reading it
+// is optional.
+type ErrorWatcher interface {
+	Errors() <-chan error
+}
+
+// errorBuffer is the number of errors an ErrorReporter keeps until they are
+// read.
+const errorBuffer = 10
+
+// ErrorReporter implements ErrorWatcher for the watchers embedding it. It
+// keeps the last errors until they are read and drops the ones that do not
+// fit, so a watcher whose errors are never read is not blocked.
+type ErrorReporter struct {
+	errs chan error
+}
+
+func NewErrorReporter() *ErrorReporter {
+	return &ErrorReporter{
+		errs: make(chan error, errorBuffer),
+	}
+}
+
+// Report queues the error, or drops it when the buffer is full.
+func (r *ErrorReporter) Report(err error) {
+	select {
+	case r.errs <- err:
+	default:
+	}
+}
+
+func (r *ErrorReporter) Errors() <-chan error {
+	return r.errs
 }
@@ -79,6 +79,10 @@ func (c *consul) String() string {
 	return c.opts.Name
 }
 
+func (c *consul) Priority() int {
+	return c.opts.Priority
+}
+
 func (c *consul) Watcher() (backend.Watcher, error) {
 	if !c.opts.Watcher {
 		return nil, nil
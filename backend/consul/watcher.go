@@ -1,7 +1,9 @@
 package consul
 
 import (
+	"fmt"
 	"log"
+	"sync"
 
 	"github.com/Ak-Army/xlog"
 	"github.com/hashicorp/consul/api"
@@ -11,18 +13,24 @@ import (
 )
 
 type watcher struct {
+	*backend.ErrorReporter
+
 	c *consul
 
 	wp   *watch.Plan
 	ch   chan *backend.Content
 	exit chan bool
+	once sync.Once
+	wg   sync.WaitGroup
+	err  error
 }
 
 func newWatcher(c *consul) (backend.Watcher, error) {
 	w := &watcher{
-		c:    c,
-		ch:   make(chan *backend.Content),
-		exit: make(chan bool),
+		ErrorReporter: backend.NewErrorReporter(),
+		c:             c,
+		ch:            make(chan *backend.Content),
+		exit:          make(chan bool),
 	}
 	wp, err := watch.Parse(map[string]interface{}{"type": "keyprefix", "prefix": c.prefix})
 	if err != nil {
@@ -39,23 +47,36 @@ func (w *watcher) handle(idx uint64, data interface{}) {
 	}
 	kvs, ok := data.(api.KVPairs)
 	if !ok {
+		w.Report(fmt.Errorf("unexpected watch data type %T", data))
 		return
 	}
 	cs, err := w.c.read(kvs)
 	if err != nil {
+		w.Report(err)
 		return
 	}
-	w.ch <- cs
+	select {
+	case w.ch <- cs:
+	case <-w.exit:
+	}
 }
 
 func (w *watcher) Watch() <-chan *backend.Content {
 	logger := xlog.FromContext(w.c.opts.Context)
-	go w.wp.RunWithClientAndLogger(w.c.client, log.New(logger, "watch:", 0)) //lint:ignore SA1019 .
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		w.err = w.wp.RunWithClientAndLogger(w.c.client, log.New(logger, "watch:", 0)) //lint:ignore SA1019 .
+	}()
 
 	return w.ch
 }
 
-func (w *watcher) Stop() {
-	w.wp.Stop()
-	close(w.exit)
+func (w *watcher) Stop() error {
+	w.once.Do(func() {
+		close(w.exit)
+		w.wp.Stop()
+	})
+	w.wg.Wait()
+	return w.err
 }
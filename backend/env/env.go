@@ -87,3 +87,7 @@ func (e *env) Watcher() (backend.Watcher, error) {
 func (e *env) String() string {
 	return e.opts.Name
 }
+
+func (e *env) Priority() int {
+	return e.opts.Priority
+}
@@ -3,23 +3,27 @@ package env
 import (
 	"fmt"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/Ak-Army/config/backend"
-
-	"github.com/pkg/errors"
 )
 
 type watcher struct {
+	*backend.ErrorReporter
+
 	e    *env
 	hash string
 	exit chan bool
+	once sync.Once
+	wg   sync.WaitGroup
 }
 
 func newWatcher(e *env) (backend.Watcher, error) {
 	w := &watcher{
-		e:    e,
-		exit: make(chan bool),
+		ErrorReporter: backend.NewErrorReporter(),
+		e:             e,
+		exit:          make(chan bool),
 	}
 	return w, w.updateHash()
 }
@@ -27,7 +31,9 @@ func newWatcher(e *env) (backend.Watcher, error) {
 func (w *watcher) Watch() <-chan *backend.Content {
 	ch := make(chan *backend.Content)
 	if w.e.defaults != "" {
+		w.wg.Add(1)
 		go func() {
+			defer w.wg.Done()
 			timer := time.NewTimer(w.e.watchInterval)
 			for {
 				select {
@@ -36,6 +42,7 @@ func (w *watcher) Watch() <-chan *backend.Content {
 				case <-timer.C:
 					lastHash := w.hash
 					if err := w.updateHash(); err != nil {
+						w.Report(err)
 						break
 					}
 					if lastHash == w.hash {
@@ -43,6 +50,7 @@ func (w *watcher) Watch() <-chan *backend.Content {
 					}
 					c, err := w.e.Read()
 					if err != nil {
+						w.Report(err)
 						break
 					}
 					select {
@@ -59,8 +67,12 @@ func (w *watcher) Watch() <-chan *backend.Content {
 	return ch
 }
 
-func (w *watcher) Stop() {
-	close(w.exit)
+func (w *watcher) Stop() error {
+	w.once.Do(func() {
+		close(w.exit)
+	})
+	w.wg.Wait()
+	return nil
 }
 
 func (w *watcher) updateHash() error {
@@ -69,12 +81,12 @@ func (w *watcher) updateHash() error {
 		if os.IsNotExist(err) {
 			return nil
 		}
-		return errors.WithMessage(err, "open file error")
+		return fmt.Errorf("open file error: %w", err)
 	}
 	defer file.Close()
 	s, err := file.Stat()
 	if err != nil {
-		return errors.WithMessage(err, "config file stat error")
+		return fmt.Errorf("config file stat error: %w", err)
 	}
 	w.hash = fmt.Sprintf("%d|%d", s.ModTime().UnixNano(), s.Size())
 	return nil
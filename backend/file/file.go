@@ -62,6 +62,10 @@ func (f *file) String() string {
 	return f.opts.Name
 }
 
+func (f *file) Priority() int {
+	return f.opts.Priority
+}
+
 func (f *file) Watcher() (backend.Watcher, error) {
 	if !f.opts.Watcher {
 		return nil, nil
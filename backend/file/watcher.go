@@ -3,30 +3,36 @@ package file
 import (
 	"fmt"
 	"os"
+	"sync"
 	"time"
 
-	"github.com/pkg/errors"
-
 	"github.com/Ak-Army/config/backend"
 )
 
 type watcher struct {
+	*backend.ErrorReporter
+
 	f    *file
 	hash string
 	exit chan bool
+	once sync.Once
+	wg   sync.WaitGroup
 }
 
 func newWatcher(f *file) (backend.Watcher, error) {
 	w := &watcher{
-		f:    f,
-		exit: make(chan bool),
+		ErrorReporter: backend.NewErrorReporter(),
+		f:             f,
+		exit:          make(chan bool),
 	}
 	return w, w.updateHash()
 }
 
 func (w *watcher) Watch() <-chan *backend.Content {
 	ch := make(chan *backend.Content)
+	w.wg.Add(1)
 	go func() {
+		defer w.wg.Done()
 		timer := time.NewTimer(w.f.watchInterval)
 		for {
 			select {
@@ -35,6 +41,7 @@ func (w *watcher) Watch() <-chan *backend.Content {
 			case <-timer.C:
 				lastHash := w.hash
 				if err := w.updateHash(); err != nil {
+					w.Report(err)
 					break
 				}
 				if lastHash == w.hash {
@@ -42,6 +49,7 @@ func (w *watcher) Watch() <-chan *backend.Content {
 				}
 				c, err := w.f.Read()
 				if err != nil {
+					w.Report(err)
 					break
 				}
 				select {
@@ -57,8 +65,12 @@ func (w *watcher) Watch() <-chan *backend.Content {
 	return ch
 }
 
-func (w *watcher) Stop() {
-	close(w.exit)
+func (w *watcher) Stop() error {
+	w.once.Do(func() {
+		close(w.exit)
+	})
+	w.wg.Wait()
+	return nil
 }
 
 func (w *watcher) updateHash() error {
@@ -67,12 +79,12 @@ func (w *watcher) updateHash() error {
 		if os.IsNotExist(err) {
 			return nil
 		}
-		return errors.WithMessage(err, "open file error")
+		return fmt.Errorf("open file error: %w", err)
 	}
 	defer file.Close()
 	s, err := file.Stat()
 	if err != nil {
-		return errors.WithMessage(err, "config file stat error")
+		return fmt.Errorf("config file stat error: %w", err)
 	}
 	w.hash = fmt.Sprintf("%d|%d", s.ModTime().UnixNano(), s.Size())
 	return nil
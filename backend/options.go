@@ -8,10 +8,11 @@ import (
 )
 
 type Options struct {
-	Name    string
-	Encoder encoder.Encoder
-	Context context.Context
-	Watcher bool
+	Name     string
+	Encoder  encoder.Encoder
+	Context  context.Context
+	Watcher  bool
+	Priority int
 }
 
 type Option func(o *Options)
@@ -51,3 +52,12 @@ func WithWatcher() Option {
 		o.Watcher = true
 	}
 }
+
+// WithPriority sets the precedence of the backend. When several backends
+// provide the same key, the one with the higher priority wins; backends with
+// equal priority are ordered by the time they were added to the Loader.
+func WithPriority(priority int) Option {
+	return func(o *Options) {
+		o.Priority = priority
+	}
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ByteSize is a size in bytes, decoded from numbers or from strings with a
+// decimal (KB, MB, GB, TB, PB) or binary (KiB, MiB, GiB, TiB, PiB) unit,
+// like "512", "10MiB" or "2GB".
+type ByteSize uint64
+
+const (
+	Byte ByteSize = 1
+
+	KB = 1000 * Byte
+	MB = 1000 * KB
+	GB = 1000 * MB
+	TB = 1000 * GB
+	PB = 1000 * TB
+
+	KiB = 1024 * Byte
+	MiB = 1024 * KiB
+	GiB = 1024 * MiB
+	TiB = 1024 * GiB
+	PiB = 1024 * TiB
+)
+
+var byteUnits = map[string]ByteSize{
+	"":    Byte,
+	"b":   Byte,
+	"k":   KiB,
+	"kb":  KB,
+	"kib": KiB,
+	"m":   MiB,
+	"mb":  MB,
+	"mib": MiB,
+	"g":   GiB,
+	"gb":  GB,
+	"gib": GiB,
+	"t":   TiB,
+	"tb":  TB,
+	"tib": TiB,
+	"p":   PiB,
+	"pb":  PB,
+	"pib": PiB,
+}
+
+// ParseByteSize parses a size like "10MiB" or "1.5GB". Units are case
+// insensitive, single letter units (K, M, G, T, P) are binary.
+func ParseByteSize(s string) (ByteSize, error) {
+	s = strings.TrimSpace(s)
+	idx := strings.IndexFunc(s, func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsSpace(r)
+	})
+	number, unit := s, ""
+	if idx != -1 {
+		number, unit = s[:idx], strings.TrimSpace(s[idx:])
+	}
+	u, ok := byteUnits[strings.ToLower(unit)]
+	if !ok {
+		return 0, fmt.Errorf("invalid byte size '%s': unknown unit '%s'", s, unit)
+	}
+	if n, err := strconv.ParseUint(number, 10, 64); err == nil {
+		if n > math.MaxUint64/uint64(u) {
+			return 0, fmt.Errorf("byte size '%s' overflows", s)
+		}
+		return ByteSize(n) * u, nil
+	}
+	f, err := strconv.ParseFloat(number, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("invalid byte size '%s'", s)
+	}
+	// written so NaN fails the check too
+	size := f * float64(u)
+	if !(size < math.MaxUint64) {
+		return 0, fmt.Errorf("byte size '%s' overflows", s)
+	}
+	return ByteSize(size), nil
+}
+
+// String formats the size with the unit that gives the smallest whole number.
+func (b ByteSize) String() string {
+	units := []struct {
+		size ByteSize
+		name string
+	}{
+		{PiB, "PiB"}, {PB, "PB"}, {TiB, "TiB"}, {TB, "TB"}, {GiB, "GiB"},
+		{GB, "GB"}, {MiB, "MiB"}, {MB, "MB"}, {KiB, "KiB"}, {KB, "KB"},
+	}
+	for _, u := range units {
+		if b >= u.size && b%u.size == 0 {
+			return fmt.Sprintf("%d%s", b/u.size, u.name)
+		}
+	}
+	return fmt.Sprintf("%dB", uint64(b))
+}
+
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	size, err := ParseByteSize(string(text))
+	if err != nil {
+		return err
+	}
+	*b = size
+	return nil
+}
+
+func (b *ByteSize) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	if s == "null" {
+		return nil
+	}
+	return b.UnmarshalText([]byte(s))
+}
+
+func (b ByteSize) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+)
+
+// Change describes a key whose value is different after a reload. Backend
+// is the backend that provides the new value, or the one that provided the
+// old value when the key disappeared.
+type Change struct {
+	Key     string
+	Old     interface{}
+	New     interface{}
+	Backend string
+}
+
+// OnChange registers a function called after a reload changed the snapshot
+// of a config. It receives every changed key, reloads without any change do
+// not call it. The function is called outside of the Loader's lock.
+func (l *Loader) OnChange(fn func(c Config, changes []Change)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.onChange = append(l.onChange, fn)
+}
+
+type notification struct {
+	config  Config
+	changes []Change
+}
+
+func (l *Loader) notify(notifications []notification) {
+	l.mu.Lock()
+	handlers := l.onChange
+	l.mu.Unlock()
+	for _, n := range notifications {
+		for _, fn := range handlers {
+			fn(n.config, n.changes)
+		}
+	}
+}
+
+type leaf struct {
+	value   interface{}
+	backend string
+}
+
+// flatten collects the values of every leaf field by their key path.
+func flatten(fields []*field) map[string]leaf {
+	values := make(map[string]leaf)
+	walk(fields, "", "", func(f *field, key, _ string) {
+		values[key] = leaf{
+			value:   printable(clone(f.value)),
+			backend: f.backend,
+		}
+	})
+	return values
+}
+
+func diff(old, new map[string]leaf) []Change {
+	var changes []Change
+	for key, n := range new {
+		o, found := old[key]
+		if found && reflect.DeepEqual(o.value, n.value) {
+			continue
+		}
+		changes = append(changes, Change{
+			Key:     key,
+			Old:     o.value,
+			New:     n.value,
+			Backend: n.backend,
+		})
+	}
+	for key, o := range old {
+		if _, found := new[key]; found {
+			continue
+		}
+		changes = append(changes, Change{
+			Key:     key,
+			Old:     o.value,
+			Backend: o.backend,
+		})
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
+
+// clone copies the value deeply, so the decoder of a later reload can not
+// modify it through a shared slice, map or pointer.
+func clone(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(clone(v.Elem()))
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(clone(v.Index(i)))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), clone(iter.Value()))
+		}
+		return c
+	}
+	return v
+}
@@ -1,93 +1,407 @@
 package config
 
 import (
+	"bytes"
 	"context"
+	stdjson "encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 
-	"github.com/pkg/errors"
-
 	"github.com/Ak-Army/config/backend"
 	"github.com/Ak-Army/config/encoder"
+	"github.com/Ak-Army/config/encoder/json"
 )
 
-var notFountError = errors.New("not found")
-
 type Loader struct {
 	mu             sync.Mutex
 	ctx            context.Context
+	cancel         context.CancelFunc
 	backend        []backend.Backend
-	backendWatcher []Config
+	backendWatcher []*watched
 	maps           map[backend.Backend]*backend.Content
+	watchers       map[backend.Backend]backend.Watcher
+	unwatch        map[backend.Backend]context.CancelFunc
+	wg             sync.WaitGroup
+	stopping       bool
+	stopErr        error
+	reloadPolicy   ReloadPolicy
+	onChange       []func(c Config, changes []Change)
+	onError        []func(err error)
+	decoders       map[reflect.Type]DecodeFunc
+	naming         NamingStrategy
+	foldCase       bool
+	strict         map[string]Strictness
+	strictDefault  Strictness
+	applyMu        sync.Mutex
+	pending        []pending
+}
+
+// pending is a snapshot built under the Loader's lock, waiting to be passed
+// to Config.SetSnapshot once the lock is released.
+type pending struct {
+	config   Config
+	snapshot interface{}
+	err      error
+}
+
+// watched is a Config registered by Load, with the values of its last
+// applied snapshot and the last snapshot rejected by the reload policy.
+type watched struct {
+	config      Config
+	snapshot    interface{}
+	values      map[string]leaf
+	provenance  []Provenance
+	rejected    interface{}
+	rejectedErr error
 }
 
 type field struct {
 	name      string
 	key       string
 	value     reflect.Value
-	origValue reflect.Value
+	elem      reflect.Value
 	required  bool
+	secret    bool
 	isList    bool
+	isMap     bool
+	inline    bool
+	entry     bool
 	source    string
+	def       string
+	hasDef    bool
+	unit      string
+	desc      string
+	rules     []rule
 	subFields []*field
+	items     []*field
+	entries   []*field
 	found     bool
+	backend   string
+	content   *backend.Content
+	fromDef   bool
+}
+
+// lookup returns the raw value of the key. A key not found as it is, but
+// containing dots, is looked up as a path through the nested data.
+func (ly layer) lookup(key string) (interface{}, bool, error) {
+	if v, found := ly.get(ly.data, key); found || !strings.Contains(key, ".") {
+		return v, found, nil
+	}
+	data := ly.data
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		v, found := ly.get(data, part)
+		if !found {
+			return nil, false, nil
+		}
+		var err error
+		data, err = ly.content.Encoder.DecodeData(v)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+	v, found := ly.get(data, parts[len(parts)-1])
+	return v, found, nil
+}
+
+// get returns the value of the key in data. With case-insensitive keys the
+// first matching key in sort order is used when there is no exact match.
+func (ly layer) get(data encoder.Data, key string) (interface{}, bool) {
+	if v, found := data[key]; found || !ly.fold {
+		return v, found
+	}
+	match, found := "", false
+	for k := range data {
+		if strings.EqualFold(k, key) && (!found || k < match) {
+			match, found = k, true
+		}
+	}
+	return data[match], found
+}
+
+func (f *field) setSource(ly layer) {
+	f.backend = ly.name
+	f.content = ly.content
+	f.fromDef = ly.def
+}
+
+// layer is the data one backend provides at a given depth of the struct.
+type layer struct {
+	name    string
+	fold    bool
+	content *backend.Content
+	data    encoder.Data
+	def     bool
 }
 
+// NewLoader creates a Loader reading from the given sources. When more than
+// one source provides the same key, sources passed later (or added later by
+// AddSource) override the earlier ones, unless backend.WithPriority says
+// otherwise.
 func NewLoader(ctx context.Context, sources ...backend.Backend) (*Loader, error) {
 	l := &Loader{
-		backend: sources,
-		ctx:     ctx,
-		maps:    make(map[backend.Backend]*backend.Content),
+		backend:  sources,
+		maps:     make(map[backend.Backend]*backend.Content),
+		watchers: make(map[backend.Backend]backend.Watcher),
+		unwatch:  make(map[backend.Backend]context.CancelFunc),
 	}
+	l.ctx, l.cancel = context.WithCancel(ctx)
 	for _, s := range l.backend {
 		if err := l.syncSource(s); err != nil {
+			l.Close()
 			return nil, err
 		}
 	}
 	return l, nil
 }
 
+// Close stops the watchers of every backend and waits until all the
+// goroutines of the Loader exit. Cancelling the context given to NewLoader
+// stops the watchers as well, Close still has to be called to wait for them.
+// A Loader without watchers runs no goroutine, closing it is optional.
+func (l *Loader) Close() error {
+	l.cancel()
+	l.wg.Wait()
+	return l.stopErr
+}
+
+// OnError registers a function called with the errors the backend watchers
+// report, and the errors of the reloads they trigger that the reload policy
+// rejects, wrapped in a *WatchError. The function is called outside of the
+// Loader's lock.
+func (l *Loader) OnError(fn func(err error)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.onError = append(l.onError, fn)
+}
+
+func (l *Loader) reportError(err error) {
+	l.mu.Lock()
+	handlers := l.onError
+	l.mu.Unlock()
+	for _, fn := range handlers {
+		fn(err)
+	}
+}
+
+func (l *Loader) stopWatchers() error {
+	l.mu.Lock()
+	sources := l.backend
+	watchers := l.watchers
+	l.watchers = make(map[backend.Backend]backend.Watcher)
+	l.mu.Unlock()
+
+	var errs []error
+	for _, s := range sources {
+		w, ok := watchers[s]
+		if !ok {
+			continue
+		}
+		if err := w.Stop(); err != nil {
+			errs = append(errs, &WatchError{
+				Backend: s.String(),
+				Err:     err,
+			})
+		}
+	}
+	return errors.Join(errs...)
+}
+
 func (l *Loader) AddSource(sources ...backend.Backend) error {
-	var gerr []string
+	var errs []error
 	for _, s := range sources {
 		if err := l.syncSource(s); err != nil {
-			gerr = append(gerr, err.Error())
+			errs = append(errs, err)
 			continue
 		}
+		l.mu.Lock()
 		l.backend = append(l.backend, s)
+		l.mu.Unlock()
 	}
-	if len(gerr) > 0 {
-		return fmt.Errorf("source loading errors: %s", strings.Join(gerr, "\n"))
+	return errors.Join(errs...)
+}
+
+// RemoveSource stops the watcher of the backend and resolves every loaded
+// config again without it. The returned error holds the error of the watcher
+// and the errors of the rebuilt snapshots.
+func (l *Loader) RemoveSource(s backend.Backend) error {
+	var notifications []notification
+	var errs []error
+	l.mu.Lock()
+	found := false
+	for i, b := range l.backend {
+		if b == s {
+			l.backend = append(l.backend[:i:i], l.backend[i+1:]...)
+			found = true
+			break
+		}
+	}
+	if !found {
+		l.mu.Unlock()
+		return &UnknownBackendError{Backend: s.String()}
+	}
+	delete(l.maps, s)
+	w := l.watchers[s]
+	delete(l.watchers, s)
+	if cancel, ok := l.unwatch[s]; ok {
+		cancel()
+		delete(l.unwatch, s)
+	}
+	for _, w := range l.backendWatcher {
+		changes, err := l.load(w, true)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if len(changes) > 0 {
+			notifications = append(notifications, notification{
+				config:  w.config,
+				changes: changes,
+			})
+		}
+	}
+	l.mu.Unlock()
+	l.apply()
+	l.notify(notifications)
+
+	if w != nil {
+		if err := w.Stop(); err != nil {
+			errs = append(errs, &WatchError{
+				Backend: s.String(),
+				Err:     err,
+			})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SetOptions changes the behaviour of the Loader, it should be called before
+// the first Load.
+func (l *Loader) SetOptions(opts ...Option) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, o := range opts {
+		o(l)
 	}
-	return nil
 }
 
 func (l *Loader) Load(c Config) error {
-	l.backendWatcher = append(l.backendWatcher, c)
 	to := c.NewSnapshot()
 	ref := reflect.ValueOf(to)
 
 	if !ref.IsValid() || ref.Kind() != reflect.Ptr || ref.Elem().Kind() != reflect.Struct {
 		return errors.New("provided target must be a pointer to struct")
 	}
-	l.load(c)
+	l.mu.Lock()
+	w := &watched{config: c}
+	l.backendWatcher = append(l.backendWatcher, w)
+	l.load(w, false)
+	l.mu.Unlock()
+	l.apply()
 	return nil
 }
 
-func (l *Loader) load(c Config) {
-	to := c.NewSnapshot()
+// Unload stops keeping the config up to date, its snapshot is not rebuilt
+// on the later reloads.
+func (l *Loader) Unload(c Config) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i, w := range l.backendWatcher {
+		if w.config == c {
+			l.backendWatcher = append(l.backendWatcher[:i:i], l.backendWatcher[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotLoaded
+}
+
+// Rejected returns the last snapshot of the config that was not applied
+// because of the reload policy, and the error it failed with. It returns
+// nil values when the last reload was applied.
+func (l *Loader) Rejected(c Config) (interface{}, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, w := range l.backendWatcher {
+		if w.config == c {
+			return w.rejected, w.rejectedErr
+		}
+	}
+	return nil, nil
+}
+
+// load resolves a new snapshot of the config and queues it for SetSnapshot,
+// the caller has to call apply after releasing the lock. On reload it
+// returns the keys changed since the previous snapshot, and the error the
+// snapshot failed with.
+func (l *Loader) load(w *watched, reload bool) ([]Change, error) {
+	to := w.config.NewSnapshot()
 	ref := reflect.ValueOf(to).Elem()
 	fields := l.parseStruct(ref)
 	err := l.resolve(fields)
-	c.SetSnapshot(to, err)
+	// the rules are checked even when keys are missing, so every violation
+	// is reported at once
+	if verr := l.validate(fields); verr != nil {
+		if err == nil {
+			err = verr
+		} else {
+			err = errors.Join(err, verr)
+		}
+	}
+	if err == nil {
+		err = callValidators(reflect.ValueOf(to), "")
+	}
+	if reload && err != nil && l.reloadPolicy != ReloadApply {
+		w.rejected, w.rejectedErr = to, err
+		if l.reloadPolicy == ReloadFailFast {
+			l.cancel()
+			return nil, fmt.Errorf("%w after a failed reload: %w", ErrClosed, err)
+		}
+		return nil, err
+	}
+	w.rejected, w.rejectedErr = nil, nil
+	w.snapshot = to
+	l.pending = append(l.pending, pending{
+		config:   w.config,
+		snapshot: to,
+		err:      err,
+	})
+
+	values := flatten(fields)
+	w.provenance = explain(fields)
+	var changes []Change
+	if reload {
+		changes = diff(w.values, values)
+	}
+	w.values = values
+	return changes, err
+}
+
+// apply passes the queued snapshots to SetSnapshot, in the order they were
+// built. It must be called without holding the Loader's lock, so SetSnapshot
+// can call the Loader's methods.
+func (l *Loader) apply() {
+	l.applyMu.Lock()
+	defer l.applyMu.Unlock()
+	l.mu.Lock()
+	queue := l.pending
+	l.pending = nil
+	l.mu.Unlock()
+	for _, p := range queue {
+		p.config.SetSnapshot(p.snapshot, p.err)
+	}
 }
 
 func (l *Loader) syncSource(s backend.Backend) error {
 	c, err := s.Read()
 	if err != nil {
-		return err
+		return &SourceReadError{
+			Backend: s.String(),
+			Err:     err,
+		}
 	}
 	l.mu.Lock()
 	defer l.mu.Unlock()
@@ -98,26 +412,96 @@ func (l *Loader) syncSource(s backend.Backend) error {
 }
 
 func (l *Loader) watch(s backend.Backend) error {
+	if l.ctx.Err() != nil {
+		return ErrClosed
+	}
 	w, err := s.Watcher()
 	if err != nil {
-		return err
+		return &WatchError{
+			Backend: s.String(),
+			Err:     err,
+		}
 	}
 	if w == nil {
 		return nil
 	}
+	if !l.stopping {
+		// started with the first watcher, so a Loader without watchers
+		// does not need to be closed
+		l.stopping = true
+		l.wg.Add(1)
+		go func() {
+			defer l.wg.Done()
+			<-l.ctx.Done()
+			l.stopErr = l.stopWatchers()
+		}()
+	}
+	l.watchers[s] = w
+	ctx, cancel := context.WithCancel(l.ctx)
+	l.unwatch[s] = cancel
 	ch := w.Watch()
+	var errs <-chan error
+	if ew, ok := w.(backend.ErrorWatcher); ok {
+		errs = ew.Errors()
+	}
+	l.wg.Add(1)
 	go func() {
+		defer l.wg.Done()
 		for {
 			select {
-			case <-l.ctx.Done():
+			case <-ctx.Done():
 				return
-			case content := <-ch:
+			case err, ok := <-errs:
+				if !ok {
+					errs = nil
+					continue
+				}
+				l.reportError(&WatchError{
+					Backend: s.String(),
+					Err:     err,
+				})
+			case content, ok := <-ch:
+				if !ok {
+					return
+				}
+				var notifications []notification
+				var rejected []error
 				l.mu.Lock()
+				if _, ok := l.maps[s]; !ok {
+					// the source was removed meanwhile
+					l.mu.Unlock()
+					return
+				}
 				l.maps[s] = content
-				for _, config := range l.backendWatcher {
-					l.load(config)
+				for _, w := range l.backendWatcher {
+					if l.ctx.Err() != nil {
+						break
+					}
+					changes, err := l.load(w, true)
+					if len(changes) > 0 {
+						notifications = append(notifications, notification{
+							config:  w.config,
+							changes: changes,
+						})
+					}
+					if err != nil && w.rejected != nil {
+						// SetSnapshot does not get the error of a rejected
+						// snapshot
+						rejected = append(rejected, &WatchError{
+							Backend: s.String(),
+							Err:     err,
+						})
+					}
 				}
 				l.mu.Unlock()
+				l.apply()
+				for _, err := range rejected {
+					l.reportError(err)
+				}
+				l.notify(notifications)
+				if err := l.CheckUnknownKeys(); err != nil {
+					l.reportError(err)
+				}
 			}
 		}
 	}()
@@ -131,181 +515,543 @@ func (l *Loader) parseStruct(ref reflect.Value) []*field {
 
 	for i := 0; i < numFields; i++ {
 		structField := t.Field(i)
-		originalValue := ref.Field(i)
-		typ := originalValue.Type()
 		if structField.PkgPath != "" {
 			continue
 		}
-
+		value := ref.Field(i)
 		tag := structField.Tag.Get("config")
-		value := reflect.New(typ).Elem()
-		value.Set(originalValue)
-		f := field{
-			name:      structField.Name,
-			key:       tag,
-			value:     value,
-			origValue: originalValue,
-			found:     false,
-		}
-		tagCheck := func() {
-			if tag == "-" {
-				list = append(list, l.parseStruct(value)...)
-			} else {
-				f.subFields = l.parseStruct(value)
-				l.parseTag(tag, &f)
-				list = append(list, &f)
-			}
+		f := &field{
+			name:  structField.Name,
+			key:   tag,
+			value: value,
 		}
-		switch typ.Kind() {
-		case reflect.Struct:
-			tagCheck()
+		l.parseTag(tag, f)
+		f.inline = tag == "-"
+		if f.key == "" {
+			f.key = l.naming.key(structField.Name)
+		}
+		l.setKind(f)
+		// untagged embedded structs are inlined, the way encoding/json does
+		if structField.Anonymous && tag == "" && len(f.subFields) != 0 {
+			f.inline = true
+		}
+		if f.inline && len(f.subFields) == 0 {
 			continue
-		case reflect.Slice:
-			if value.Type().Elem().Kind() == reflect.Struct {
-				value = reflect.New(value.Type().Elem()).Elem()
-				if tag == "-" {
-					continue
-				}
-				f.isList = true
-				f.value = f.origValue
-				tagCheck()
-				continue
-			}
-		case reflect.Ptr:
-			if originalValue.Type().Elem().Kind() == reflect.Struct {
-				if originalValue.IsNil() {
-					value = reflect.New(originalValue.Type().Elem())
-				}
-				value = value.Elem()
-				tagCheck()
-				continue
-			}
-		default:
-			if tag == "-" {
-				continue
+		}
+		list = append(list, f)
+	}
+
+	return list
+}
+
+// setKind prepares the field for the resolution according to its type.
+func (l *Loader) setKind(f *field) {
+	typ := f.value.Type()
+	if _, ok := l.decoder(typ); ok {
+		return
+	}
+	switch {
+	case typ.Kind() == reflect.Struct ||
+		typ.Kind() == reflect.Ptr && typ.Elem().Kind() == reflect.Struct:
+		f.elem = f.value
+		if typ.Kind() == reflect.Ptr {
+			if f.value.IsNil() {
+				f.elem = reflect.New(typ.Elem()).Elem()
+			} else {
+				f.elem = f.value.Elem()
 			}
 		}
-		l.parseTag(tag, &f)
-		list = append(list, &f)
+		f.subFields = l.parseStruct(f.elem)
+		if f.secret {
+			setSecret(f.subFields)
+		}
+	case (typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array) && l.itemwise(typ.Elem()):
+		f.isList = true
+	case typ.Kind() == reflect.Map && typ.Key().Kind() == reflect.String:
+		f.isMap = true
+	}
+}
 
+// itemwise reports whether the elements of a list of the type are resolved
+// one by one, because they are composite, have their own decoder or are
+// durations, which are decoded with the unit of the field.
+func (l *Loader) itemwise(typ reflect.Type) bool {
+	if _, ok := l.decoder(typ); ok {
+		return true
 	}
+	switch {
+	case typ == durationType,
+		typ.Kind() == reflect.Ptr && typ.Elem() == durationType:
+		return true
+	case typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array:
+		return l.itemwise(typ.Elem())
+	case typ.Kind() == reflect.Map && typ.Key().Kind() == reflect.String:
+		return l.itemwise(typ.Elem())
+	}
+	return l.composite(typ)
+}
 
-	return list
+// composite reports whether values of the type are resolved field by field,
+// instead of being decoded as a whole.
+func (l *Loader) composite(typ reflect.Type) bool {
+	if _, ok := l.decoder(typ); ok {
+		return false
+	}
+	switch typ.Kind() {
+	case reflect.Struct:
+		return true
+	case reflect.Ptr:
+		return typ.Elem().Kind() == reflect.Struct
+	case reflect.Slice, reflect.Array:
+		return l.composite(typ.Elem())
+	case reflect.Map:
+		return typ.Key().Kind() == reflect.String && l.composite(typ.Elem())
+	}
+	return false
+}
+
+// setSecret marks the fields and everything below them as secret, the secret
+// option of a struct covers its whole subtree.
+func setSecret(fields []*field) {
+	for _, f := range fields {
+		f.secret = true
+		setSecret(f.subFields)
+	}
+}
+
+// newItem returns the field of the i-th element of a list.
+func (l *Loader) newItem(f *field, list reflect.Value, i int) *field {
+	key := fmt.Sprintf("[%d]", i)
+	e := &field{
+		name:   key,
+		key:    key,
+		value:  list.Index(i),
+		secret: f.secret,
+		unit:   f.unit,
+	}
+	l.setKind(e)
+	return e
+}
+
+// newEntry returns the field of a map entry, starting from the value the map
+// already has for the name.
+func (l *Loader) newEntry(f *field, m reflect.Value, name string) *field {
+	e := &field{
+		name:   fmt.Sprintf("[%s]", name),
+		key:    name,
+		value:  reflect.New(m.Type().Elem()).Elem(),
+		secret: f.secret,
+		unit:   f.unit,
+		entry:  true,
+	}
+	if !m.IsNil() {
+		if v := m.MapIndex(reflect.ValueOf(name).Convert(m.Type().Key())); v.IsValid() {
+			e.value.Set(v)
+		}
+	}
+	l.setKind(e)
+	return e
 }
 
 func (l *Loader) parseTag(tag string, f *field) {
 	if idx := strings.Index(tag, ","); idx != -1 {
-		f.key = tag[:idx]
-		opts := strings.Split(tag[idx+1:], ",")
+		opts := splitTag(tag)
+		f.key = opts[0]
 
-		for _, opt := range opts {
+		for _, opt := range opts[1:] {
 			if opt == "required" {
 				f.required = true
 			}
+			if opt == "secret" {
+				f.secret = true
+			}
 			if strings.HasPrefix(opt, "backend=") {
 				f.source = opt[len("backend="):]
 			}
+			if strings.HasPrefix(opt, "unit=") {
+				f.unit = opt[len("unit="):]
+			}
+			if strings.HasPrefix(opt, "desc=") {
+				f.desc = opt[len("desc="):]
+				if desc, err := strconv.Unquote(f.desc); err == nil {
+					f.desc = desc
+				}
+			}
+			if strings.HasPrefix(opt, "default=") {
+				f.def = opt[len("default="):]
+				f.hasDef = true
+			}
+			if r, ok := parseRule(opt); ok {
+				f.rules = append(f.rules, r)
+			}
 		}
 	}
 }
 
-func (l *Loader) resolve(fields []*field) error {
-	var gerr []string
-	for _, f := range fields {
-		var backendFound bool
-		for s, data := range l.maps {
-			if f.source != "" && f.source != s.String() {
-				continue
-			}
-			backendFound = true
-			if err := l.getFieldData(f, data, data.Data); err != nil {
-				if !errors.Is(err, notFountError) {
-					gerr = append(gerr, err.Error())
-				}
-				continue
-			}
-			break
+// splitTag splits the tag on commas, except the ones inside quotes,
+// brackets or braces, so option values like default=[1,2] stay in one piece.
+func splitTag(tag string) []string {
+	var (
+		opts   []string
+		depth  int
+		quoted bool
+		start  int
+	)
+	for i := 0; i < len(tag); i++ {
+		switch c := tag[i]; {
+		case c == '\\' && quoted:
+			i++
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '[' || c == '{' || c == '(':
+			depth++
+		case c == ']' || c == '}' || c == ')':
+			depth--
+		case c == ',' && depth <= 0:
+			opts = append(opts, tag[start:i])
+			start = i + 1
 		}
-		if f.found {
-			f.origValue.Set(f.value)
+	}
+	return append(opts, tag[start:])
+}
+
+// defaultLayer returns the default value of the field as the lowest layer,
+// so it is decoded by the same path as the values coming from the backends.
+func defaultLayer(f *field) layer {
+	raw := defaultJSON(f.def)
+	return layer{
+		name: "default",
+		content: &backend.Content{
+			Encoder: json.New(),
+			Source:  "default",
+		},
+		data: encoder.Data{f.key: stdjson.RawMessage(raw)},
+		def:  true,
+	}
+}
+
+// defaultJSON returns the default value of a tag as JSON. Values that are
+// not valid JSON are taken as strings.
+func defaultJSON(def string) []byte {
+	if stdjson.Valid([]byte(def)) {
+		return []byte(def)
+	}
+	raw, _ := stdjson.Marshal(def)
+	return raw
+}
+
+// sources returns the loaded backends ordered by precedence, the backend
+// that wins comes first. Higher priority wins, and on equal priority the
+// backend added later overrides the earlier one.
+func (l *Loader) sources() []backend.Backend {
+	list := make([]backend.Backend, 0, len(l.backend))
+	for i := len(l.backend) - 1; i >= 0; i-- {
+		if _, ok := l.maps[l.backend[i]]; ok {
+			list = append(list, l.backend[i])
 		}
-		if f.source != "" && !backendFound {
-			return fmt.Errorf("the backend: '%s' is not supported", f.source)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return priority(list[i]) > priority(list[j])
+	})
+	return list
+}
+
+func priority(b backend.Backend) int {
+	if p, ok := b.(backend.Prioritized); ok {
+		return p.Priority()
+	}
+	return 0
+}
+
+func (l *Loader) layers() []layer {
+	sources := l.sources()
+	list := make([]layer, len(sources))
+	for i, s := range sources {
+		list[i] = layer{
+			name:    s.String(),
+			fold:    l.foldCase,
+			content: l.maps[s],
+			data:    l.maps[s].Data,
+		}
+	}
+	return list
+}
+
+func (l *Loader) hasBackend(name string) bool {
+	for s := range l.maps {
+		if s.String() == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *Loader) resolve(fields []*field) error {
+	errs := l.resolveFields(fields, l.layers())
+	if missing := missingKeys(fields, "", ""); len(missing) > 0 {
+		if len(errs) == 0 {
+			return missing
+		}
+		errs = append(errs, missing)
+	}
+	return errors.Join(errs...)
+}
+
+// missingKeys collects the required fields that were not found, at every
+// depth. The fields of a nil pointer to struct are not required, only the
+// pointer itself when it is tagged so.
+func missingKeys(fields []*field, key, name string) MissingKeysError {
+	var missing MissingKeysError
+	for _, f := range fields {
+		fieldKey, fieldName := key, name
+		if !f.inline {
+			fieldKey = joinKey(key, f.key)
+			fieldName = joinKey(name, f.name)
 		}
 		if f.required && !f.found {
-			return fmt.Errorf("required key '%s' for field '%s' not found", f.key, f.name)
+			missing = append(missing, &MissingKeyError{
+				Key:   fieldKey,
+				Field: fieldName,
+			})
+			continue
 		}
-		if len(f.subFields) != 0 {
-			for _, subF := range f.subFields {
-				if subF.required && !subF.found {
-					return fmt.Errorf("required key '%s' for field '%s' not found", subF.key, subF.name)
-				}
+		switch {
+		case f.isList:
+			missing = append(missing, missingKeys(f.items, fieldKey, fieldName)...)
+		case f.isMap:
+			missing = append(missing, missingKeys(f.entries, fieldKey, fieldName)...)
+		case len(f.subFields) != 0:
+			if f.value.Kind() == reflect.Ptr && f.value.IsNil() {
+				continue
 			}
+			missing = append(missing, missingKeys(f.subFields, fieldKey, fieldName)...)
 		}
 	}
-	if len(gerr) > 0 {
-		return fmt.Errorf("data loading errors: %s", strings.Join(gerr, "\n"))
-	}
-	return nil
+	return missing
 }
 
-func (l *Loader) getFieldData(f *field, c *backend.Content, data encoder.Data) error {
-	v, found := data[f.key]
-	if !found {
-		return errors.WithMessage(notFountError, fmt.Sprintf("data %s", f.key))
+// resolveField fills the field from the given layers. Leaves and lists are
+// taken from the first layer that has them, nested structs are merged field
+// by field, so every leaf is resolved on its own across all the layers.
+func (l *Loader) resolveField(f *field, layers []layer) []error {
+	var errs []error
+	if f.source != "" {
+		if !l.hasBackend(f.source) {
+			return []error{&UnknownBackendError{
+				Backend: f.source,
+				Key:     f.key,
+				Field:   f.name,
+			}}
+		}
+		var filtered []layer
+		for _, ly := range layers {
+			if ly.name == f.source {
+				filtered = append(filtered, ly)
+			}
+		}
+		layers = filtered
+	}
+	if f.hasDef {
+		layers = append(layers[:len(layers):len(layers)], defaultLayer(f))
 	}
 
-	if len(f.subFields) != 0 {
-		if f.isList {
-			newDatas, err := c.Encoder.DecodeDataList(v)
+	switch {
+	case f.inline:
+		errs = append(errs, l.resolveFields(f.subFields, layers)...)
+		for _, subF := range f.subFields {
+			f.found = f.found || subF.found
+		}
+	case len(f.subFields) != 0:
+		var subLayers []layer
+		for _, ly := range layers {
+			v, found, err := ly.lookup(f.key)
 			if err != nil {
-				return err
+				errs = append(errs, decodeError(f, ly, err))
+				continue
 			}
-			val := reflect.MakeSlice(f.value.Type(), len(newDatas), len(newDatas))
-			f.value.Set(val)
-			for i, newData := range newDatas {
-				for a, subF := range f.subFields {
-					subF.value = reflect.New(subF.value.Type()).Elem()
-					f.subFields[a].value = subF.value
-					if err := l.getFieldData(subF, c, newData); err != nil {
-						continue
-					}
-					f.value.Index(i).Field(a).Set(subF.value)
-				}
+			if !found {
+				continue
 			}
-			f.found = true
-			return nil
+			data, err := ly.content.Encoder.DecodeData(v)
+			if err != nil {
+				errs = append(errs, decodeError(f, ly, err))
+				continue
+			}
+			subLayer := ly
+			subLayer.data = data
+			subLayers = append(subLayers, subLayer)
 		}
-		newData, err := c.Encoder.DecodeData(v)
-		if err != nil {
-			return err
+		// the sub fields are resolved even when no layer has the key, their
+		// defaults still apply
+		errs = append(errs, withPath(f, l.resolveFields(f.subFields, subLayers))...)
+		f.found = len(subLayers) != 0
+		for _, subF := range f.subFields {
+			f.found = f.found || subF.found
 		}
-		for a, subF := range f.subFields {
-			origValue := f.value
-			kind := f.value.Type().Kind()
-			if kind == reflect.Ptr && f.value.IsNil() {
-				f.value = reflect.New(f.value.Type().Elem())
+	case f.isMap:
+		var subLayers []layer
+		names := make(map[string]bool)
+		for _, ly := range layers {
+			v, found, err := ly.lookup(f.key)
+			if err != nil {
+				errs = append(errs, decodeError(f, ly, err))
+				continue
 			}
-			if err := l.getFieldData(subF, c, newData); err != nil {
-				f.value = origValue
+			if !found {
 				continue
 			}
-			if kind == reflect.Struct {
-				f.value.Field(a).Set(subF.value)
-			} else {
-				f.value.Elem().Field(a).Set(subF.value)
+			data, err := ly.content.Encoder.DecodeData(v)
+			if err != nil {
+				errs = append(errs, decodeError(f, ly, err))
+				continue
 			}
-
+			for name := range data {
+				names[name] = true
+			}
+			subLayer := ly
+			subLayer.data = data
+			subLayers = append(subLayers, subLayer)
 		}
+		if len(subLayers) == 0 {
+			return errs
+		}
+		val := reflect.MakeMap(f.value.Type())
+		if !f.value.IsNil() {
+			iter := f.value.MapRange()
+			for iter.Next() {
+				val.SetMapIndex(iter.Key(), iter.Value())
+				names[iter.Key().String()] = true
+			}
+		}
+		sorted := make([]string, 0, len(names))
+		for name := range names {
+			sorted = append(sorted, name)
+		}
+		sort.Strings(sorted)
+		f.entries = make([]*field, len(sorted))
+		for i, name := range sorted {
+			e := l.newEntry(f, f.value, name)
+			errs = append(errs, withPath(f, l.resolveField(e, subLayers))...)
+			if e.found {
+				val.SetMapIndex(reflect.ValueOf(name).Convert(f.value.Type().Key()), e.value)
+			}
+			f.entries[i] = e
+		}
+		f.value.Set(val)
 		f.found = true
-		return nil
+	case f.isList:
+		for _, ly := range layers {
+			v, found, err := ly.lookup(f.key)
+			if err != nil {
+				errs = append(errs, decodeError(f, ly, err))
+				continue
+			}
+			if !found {
+				continue
+			}
+			listErrs, ok := l.decodeList(f, ly, v)
+			errs = append(errs, listErrs...)
+			if !ok {
+				continue
+			}
+			f.found = true
+			f.setSource(ly)
+			break
+		}
+	default:
+		for _, ly := range layers {
+			v, found, err := ly.lookup(f.key)
+			if err != nil {
+				errs = append(errs, decodeError(f, ly, err))
+				continue
+			}
+			if !found {
+				continue
+			}
+			to := reflect.New(f.value.Type())
+			to.Elem().Set(f.value)
+			if err = l.decode(f, ly.content.Encoder, v, to); err != nil {
+				errs = append(errs, decodeError(f, ly, err))
+				continue
+			}
+			f.value.Set(to.Elem())
+			f.found = true
+			f.setSource(ly)
+			break
+		}
+	}
+
+	if f.found && f.value.Kind() == reflect.Ptr && f.value.IsNil() && f.elem.IsValid() {
+		f.value.Set(f.elem.Addr())
 	}
-	var to interface{}
-	if f.value.CanAddr() {
-		to = f.value.Addr().Interface()
+	return errs
+}
+
+// decodeList splits the raw list into its elements and resolves every one
+// of them as a field, so elements can be structs, pointers to structs, maps
+// or lists themselves. It returns false if the raw value is not a list.
+func (l *Loader) decodeList(f *field, ly layer, raw interface{}) ([]error, bool) {
+	var elems []stdjson.RawMessage
+	if err := ly.content.Encoder.Decode(raw, &elems); err != nil {
+		return []error{decodeError(f, ly, err)}, false
+	}
+	typ := f.value.Type()
+	var val reflect.Value
+	if typ.Kind() == reflect.Array {
+		if len(elems) > typ.Len() {
+			err := fmt.Errorf("too many elements for %s: %d", typ, len(elems))
+			return []error{decodeError(f, ly, err)}, false
+		}
+		val = reflect.New(typ).Elem()
 	} else {
-		to = f.value.Interface()
+		val = reflect.MakeSlice(typ, len(elems), len(elems))
+	}
+	var errs []error
+	f.items = make([]*field, len(elems))
+	for i, elem := range elems {
+		e := l.newItem(f, val, i)
+		f.items[i] = e
+		if elem = bytes.TrimSpace(elem); len(elem) == 0 || string(elem) == "null" {
+			continue
+		}
+		item := ly
+		item.data = encoder.Data{e.key: elem}
+		errs = append(errs, withPath(f, l.resolveField(e, []layer{item}))...)
+	}
+	f.value.Set(val)
+	return errs, true
+}
+
+// walk calls fn for every leaf field with its key path and Go field path.
+// Lists are walked item by item, nil pointers to structs are skipped.
+func walk(fields []*field, key, name string, fn func(f *field, key, name string)) {
+	for _, f := range fields {
+		fieldKey, fieldName := key, name
+		if !f.inline {
+			fieldKey = joinKey(key, f.key)
+			fieldName = joinKey(name, f.name)
+		}
+		switch {
+		case f.isList:
+			walk(f.items, fieldKey, fieldName, fn)
+		case f.isMap:
+			walk(f.entries, fieldKey, fieldName, fn)
+		case len(f.subFields) != 0:
+			if f.value.Kind() == reflect.Ptr && f.value.IsNil() {
+				continue
+			}
+			walk(f.subFields, fieldKey, fieldName, fn)
+		default:
+			fn(f, fieldKey, fieldName)
+		}
+	}
+}
+
+func (l *Loader) resolveFields(fields []*field, layers []layer) []error {
+	var errs []error
+	for _, f := range fields {
+		errs = append(errs, l.resolveField(f, layers)...)
 	}
-	f.found = true
-	return c.Encoder.Decode(v, to)
+	return errs
 }
@@ -2,19 +2,29 @@ package config
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
 	"sync"
+	"syscall"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/suite"
+	"go.uber.org/goleak"
 
 	"github.com/Ak-Army/config/backend"
 	"github.com/Ak-Army/config/backend/env"
 	"github.com/Ak-Army/config/backend/file"
+	"github.com/Ak-Army/config/encoder"
+	"github.com/Ak-Army/config/encoder/json"
 	"github.com/Ak-Army/config/encoder/toml"
 	"github.com/Ak-Army/config/encoder/yaml"
 )
@@ -278,6 +288,534 @@ func (suite *ConfigTestSuite) TestBackendTagNOK() {
 	suite.EqualError(c.err, "required key 'alma' for field 'Alma' not found")
 }
 
+func (suite *ConfigTestSuite) TestMissingRequired() {
+	type leaf struct {
+		Key   string `config:"key,required"`
+		Other string `config:"other"`
+	}
+	type section struct {
+		Name  string `config:"name,required"`
+		Inner leaf   `config:"inner"`
+	}
+	type test struct {
+		Name     string          `config:"name,required"`
+		Section  section         `config:"section"`
+		Optional *section        `config:"optional"`
+		Present  *section        `config:"present"`
+		Needed   *section        `config:"needed,required"`
+		Items    []leaf          `config:"items"`
+		Entries  map[string]leaf `config:"entries"`
+	}
+	loader, err := NewLoader(suite.ctx, file.New(file.WithPath(
+		suite.createFileForTest([]byte(`{"section":{"inner":{"other":"x"}},"present":{"inner":{"key":"k"}},
+"items":[{"key":"k"},{"other":"x"}],"entries":{"a":{"other":"x"}}}`)).Name(),
+	)))
+	suite.Require().Nil(err)
+	c := &config{
+		structs: &test{},
+	}
+	suite.Nil(loader.Load(c))
+
+	var missing MissingKeysError
+	suite.Require().True(errors.As(c.err, &missing))
+	suite.Equal(MissingKeysError{
+		{Key: "name", Field: "Name"},
+		{Key: "section.name", Field: "Section.Name"},
+		{Key: "section.inner.key", Field: "Section.Inner.Key"},
+		{Key: "present.name", Field: "Present.Name"},
+		{Key: "needed", Field: "Needed"},
+		{Key: "items[1].key", Field: "Items[1].Key"},
+		{Key: "entries.a.key", Field: "Entries[a].Key"},
+	}, missing)
+	var first *MissingKeyError
+	suite.True(errors.As(c.err, &first))
+	suite.Equal("name", first.Key)
+	suite.Contains(c.err.Error(), "missing required keys: required key 'name' for field 'Name' not found\n")
+}
+
+func (suite *ConfigTestSuite) TestErrors() {
+	type item struct {
+		Age int `config:"age"`
+	}
+	type nested struct {
+		Items []item `config:"items"`
+		Store string `config:"store,backend=missing"`
+	}
+	type test struct {
+		Nested   nested `config:"nested"`
+		Required string `config:"required,required"`
+	}
+	f := suite.createFileForTest([]byte(`{"nested":{"items":[{"age":1},{"age":"old"}]}}`))
+	source := file.New(file.WithPath(f.Name()))
+	loader, err := NewLoader(suite.ctx, source)
+	suite.Require().Nil(err)
+	c := &config{
+		structs: &test{},
+	}
+	suite.Nil(loader.Load(c))
+
+	var derr *DecodeError
+	suite.Require().True(errors.As(c.err, &derr))
+	suite.Equal("nested.items[1].age", derr.Key)
+	suite.Equal("Nested.Items[1].Age", derr.Field)
+	suite.Equal("file", derr.Backend)
+	suite.Equal("file", derr.Source)
+	suite.NotNil(errors.Unwrap(derr))
+	var berr *UnknownBackendError
+	suite.Require().True(errors.As(c.err, &berr))
+	suite.Equal(UnknownBackendError{Backend: "missing", Key: "nested.store", Field: "Nested.Store"}, *berr)
+	var missing MissingKeysError
+	suite.Require().True(errors.As(c.err, &missing))
+	suite.Equal("required", missing[0].Key)
+
+	err = loader.AddSource(file.New(file.WithPath(filepath.Join(os.TempDir(), "config-not-exists.json"))))
+	var rerr *SourceReadError
+	suite.Require().True(errors.As(err, &rerr))
+	suite.Equal("file", rerr.Backend)
+	suite.True(errors.Is(err, os.ErrNotExist))
+
+	suite.True(errors.As(loader.RemoveSource(file.New()), &berr))
+	suite.Equal(&UnknownBackendError{Backend: "file"}, berr)
+	suite.True(errors.Is(loader.Unload(&config{}), ErrNotLoaded))
+	suite.Nil(loader.Close())
+	suite.True(errors.Is(loader.Reload(context.Background()), ErrClosed))
+}
+
+func (suite *ConfigTestSuite) TestStrict() {
+	type nested struct {
+		Key string `config:"key"`
+	}
+	type first struct {
+		RecallCheckInterval int               `config:"recall-check-interval"`
+		Nested              *nested           `config:"nested"`
+		Dotted              string            `config:"dotted.key"`
+		Tags                []string          `config:"tags"`
+		Items               []nested          `config:"items"`
+		Entries             map[string]nested `config:"entries"`
+	}
+	type second struct {
+		Name string `config:"name"`
+	}
+	suite.Nil(os.Setenv("STRICT_NAME", "name"))
+	defer os.Unsetenv("STRICT_NAME")
+	suite.Nil(os.Setenv("STRICT_OTHER", "other"))
+	defer os.Unsetenv("STRICT_OTHER")
+	f := suite.createFileForTest([]byte(`{"recal-check-interval":1,"nested":{"key":"k","kye":"k"},
+"dotted":{"key":"d","other":"o"},"tags":["a"],"items":[{"key":"x"},{"nmae":"x"}],
+"entries":{"a":{"key":"x"},"b":{"any":"x"}},"name":"n"}`))
+	loader, err := NewLoader(suite.ctx,
+		file.New(file.WithPath(f.Name())),
+		env.New(env.WithStripPrefix("STRICT_"), env.WithOption(backend.WithName("strict-env"))),
+	)
+	suite.Require().Nil(err)
+	loader.SetOptions(WithStrict(StrictError), WithStrict(StrictWarn, "strict-env"))
+	var warnings []string
+	loader.OnError(func(err error) {
+		var uerr *UnknownKeyError
+		if errors.As(err, &uerr) && uerr.Key == "other" {
+			warnings = append(warnings, err.Error())
+		}
+	})
+	suite.Nil(loader.Load(&config{structs: &first{}}))
+	suite.Nil(loader.Load(&config{structs: &second{}}))
+
+	err = loader.CheckUnknownKeys()
+	var unknown []string
+	for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var uerr *UnknownKeyError
+		suite.Require().True(errors.As(err, &uerr))
+		suite.Equal("file", uerr.Backend)
+		unknown = append(unknown, uerr.Key)
+	}
+	suite.Equal([]string{"dotted.other", "entries.b.any", "items[1].nmae", "nested.kye", "recal-check-interval"}, unknown)
+	suite.Equal([]string{"unknown key 'other' in backend 'strict-env'"}, warnings)
+
+	loader.SetOptions(WithStrict(StrictOff))
+	suite.Nil(loader.CheckUnknownKeys())
+}
+
+func (suite *ConfigTestSuite) TestJSONSchema() {
+	type server struct {
+		Host string `config:"host,required,nonempty"`
+		Port int    `config:"port,default=8080,min=1,max=65535"`
+	}
+	type Base struct {
+		Debug bool `config:"debug"`
+	}
+	type Service struct {
+		Base     `config:"-"`
+		Name     string            `config:"name,required,desc=\"The name, as shown in the logs\""`
+		Mode     string            `config:"mode,oneof=a|b,regex=^[a-z]$"`
+		Level    int               `config:"level,oneof=1|2"`
+		Timeout  time.Duration     `config:"timeout,unit=s,min=1s"`
+		Size     ByteSize          `config:"size"`
+		Password string            `config:"password,secret"`
+		Server   *server           `config:"server"`
+		Backups  []server          `config:"backups,len=2"`
+		Labels   map[string]string `config:"labels"`
+		URL      *url.URL          `config:"url"`
+		Started  time.Time         `config:"started"`
+		Key      string            `config:"nested.key,required"`
+		Ignored  string
+	}
+	loader, err := NewLoader(suite.ctx)
+	suite.Require().Nil(err)
+	b, err := loader.JSONSchema(&Service{})
+	suite.Require().Nil(err)
+	serverSchema := `{"type":"object","properties":{
+		"host":{"type":"string","minLength":1},
+		"port":{"type":"integer","default":8080,"minimum":1,"maximum":65535}},
+		"required":["host"]}`
+	suite.JSONEq(`{
+		"$schema":"https://json-schema.org/draft/2020-12/schema",
+		"title":"Service",
+		"type":"object",
+		"properties":{
+			"debug":{"type":"boolean"},
+			"name":{"type":"string","description":"The name, as shown in the logs"},
+			"mode":{"type":"string","enum":["a","b"],"pattern":"^[a-z]$"},
+			"level":{"type":"integer","enum":[1,2]},
+			"timeout":{"type":["string","number"]},
+			"size":{"type":["string","integer"]},
+			"password":{"type":"string","writeOnly":true},
+			"server":`+serverSchema+`,
+			"backups":{"type":"array","items":`+serverSchema+`,"minItems":2,"maxItems":2},
+			"labels":{"type":"object","additionalProperties":{"type":"string"}},
+			"url":{"type":"string"},
+			"started":{"type":"string"},
+			"nested":{"type":"object","properties":{"key":{"type":"string"}},"required":["key"]}
+		},
+		"required":["name"]
+	}`, string(b))
+
+	_, err = loader.JSONSchema(Service{})
+	suite.Error(err)
+}
+
+func (suite *ConfigTestSuite) TestDescribe() {
+	type server struct {
+		Host string `config:"host,required"`
+	}
+	type Base struct {
+		Debug bool `config:"debug"`
+	}
+	type test struct {
+		Base     `config:"-"`
+		Name     string            `config:"name,required,desc=\"The name, as shown in the logs\""`
+		Timeout  time.Duration     `config:"timeout,default=30,unit=s"`
+		Password string            `config:"password,secret,backend=vault"`
+		Server   *server           `config:"server"`
+		DB       *server           `config:"db,required,desc=primary database,backend=file"`
+		Backups  []server          `config:"backups"`
+		Labels   map[string]server `config:"labels,backend=consul"`
+	}
+	loader, err := NewLoader(suite.ctx)
+	suite.Require().Nil(err)
+	keys, err := loader.Describe(&test{})
+	suite.Require().Nil(err)
+	suite.Equal([]KeyInfo{
+		{Key: "debug", Field: "Debug", Type: "bool"},
+		{Key: "name", Field: "Name", Type: "string", Required: true, Description: "The name, as shown in the logs"},
+		{Key: "timeout", Field: "Timeout", Type: "time.Duration", Default: "30", HasDefault: true, Unit: "s"},
+		{Key: "password", Field: "Password", Type: "string", Secret: true, Backend: "vault"},
+		{Key: "server", Field: "Server", Type: "*config.server"},
+		{Key: "server.host", Field: "Server.Host", Type: "string", Required: true},
+		{Key: "db", Field: "DB", Type: "*config.server", Required: true, Description: "primary database",
+			Backend: "file"},
+		{Key: "db.host", Field: "DB.Host", Type: "string", Required: true, Backend: "file"},
+		{Key: "backups", Field: "Backups", Type: "[]config.server"},
+		{Key: "backups[].host", Field: "Backups[].Host", Type: "string", Required: true},
+		{Key: "labels", Field: "Labels", Type: "map[string]config.server", Backend: "consul"},
+		{Key: "labels.*.host", Field: "Labels[*].Host", Type: "string", Required: true, Backend: "consul"},
+	}, keys)
+}
+
+func (suite *ConfigTestSuite) TestPrecedence() {
+	type test struct {
+		First  string `config:"first"`
+		Second string `config:"second"`
+		Third  string `config:"third"`
+	}
+
+	for i := 0; i < 20; i++ {
+		loader, err := NewLoader(suite.ctx,
+			file.New(file.WithPath(
+				suite.createFileForTest([]byte(`{"first":"a","second":"a","third":"a"}`)).Name(),
+			)),
+			file.New(file.WithPath(
+				suite.createFileForTest([]byte(`{"second":"b","third":"b"}`)).Name(),
+			)),
+		)
+		suite.Nil(err)
+		err = loader.AddSource(
+			file.New(file.WithPath(
+				suite.createFileForTest([]byte(`{"third":"c"}`)).Name(),
+			)),
+		)
+		suite.Nil(err)
+		cfg := &test{}
+		c := &config{
+			structs: cfg,
+		}
+		err = loader.Load(c)
+		suite.Nil(err)
+		suite.Nil(c.err)
+		suite.Equal(&test{
+			First:  "a",
+			Second: "b",
+			Third:  "c",
+		}, cfg)
+	}
+}
+
+func (suite *ConfigTestSuite) TestPrecedenceWithPriority() {
+	type test struct {
+		First  string `config:"first"`
+		Second string `config:"second"`
+		Third  string `config:"third"`
+	}
+
+	for i := 0; i < 20; i++ {
+		loader, err := NewLoader(suite.ctx,
+			file.New(file.WithPath(
+				suite.createFileForTest([]byte(`{"first":"a","second":"a","third":"a"}`)).Name(),
+			), file.WithOption(backend.WithPriority(10))),
+			file.New(file.WithPath(
+				suite.createFileForTest([]byte(`{"first":"b","second":"b","third":"b"}`)).Name(),
+			), file.WithOption(backend.WithPriority(5))),
+			file.New(file.WithPath(
+				suite.createFileForTest([]byte(`{"second":"c","third":"c"}`)).Name(),
+			), file.WithOption(backend.WithPriority(10))),
+			env.New(env.WithDefaults(
+				suite.createFileForTest([]byte(`THIRD="d"`)).Name(),
+			), env.WithOption(backend.WithPriority(-1))),
+		)
+		suite.Nil(err)
+		cfg := &test{}
+		c := &config{
+			structs: cfg,
+		}
+		err = loader.Load(c)
+		suite.Nil(err)
+		suite.Nil(c.err)
+		suite.Equal(&test{
+			First:  "a",
+			Second: "c",
+			Third:  "c",
+		}, cfg)
+	}
+}
+
+func (suite *ConfigTestSuite) TestDefault() {
+	type nested struct {
+		Key   string `config:"key"`
+		Other string `config:"other,default=other"`
+	}
+	type test struct {
+		Int      int           `config:"int,default=400"`
+		String   string        `config:"string,default=default string"`
+		Quoted   string        `config:"quoted,default=\"a,b\""`
+		Duration time.Duration `config:"duration,default=30"`
+		Bool     bool          `config:"bool,default=true"`
+		Slice    []string      `config:"slice,default=[\"a\",\"b\"]"`
+		Required string        `config:"required,required,default=set"`
+		Override string        `config:"override,default=default"`
+		Nested   nested        `config:"nested,default={\"key\":\"default\",\"other\":\"nested\"}"`
+		Ptr      *nested       `config:"ptr,default={\"key\":\"ptr\"}"`
+	}
+
+	loader, err := NewLoader(suite.ctx,
+		file.New(file.WithPath(
+			suite.createFileForTest([]byte(`{"override":"file","nested":{"key":"file"}}`)).Name(),
+		)),
+	)
+	suite.Nil(err)
+	cfg := &test{}
+	c := &config{
+		structs: cfg,
+	}
+	err = loader.Load(c)
+	suite.Nil(err)
+	suite.Nil(c.err)
+	suite.Equal(&test{
+		Int:      400,
+		String:   "default string",
+		Quoted:   "a,b",
+		Duration: 30,
+		Bool:     true,
+		Slice:    []string{"a", "b"},
+		Required: "set",
+		Override: "file",
+		Nested: nested{
+			Key:   "file",
+			Other: "nested",
+		},
+		Ptr: &nested{
+			Key:   "ptr",
+			Other: "other",
+		},
+	}, cfg)
+
+	type db struct {
+		Host string `config:"host,required,default=localhost"`
+		Port int    `config:"port"`
+	}
+	type missing struct {
+		DB    db  `config:"db"`
+		Ptr   *db `config:"ptr"`
+		Empty *struct {
+			Name string `config:"name"`
+		} `config:"empty"`
+	}
+	loader, err = NewLoader(suite.ctx,
+		file.New(file.WithPath(suite.createFileForTest([]byte(`{}`)).Name())),
+	)
+	suite.Nil(err)
+	missingCfg := &missing{}
+	c = &config{
+		structs: missingCfg,
+	}
+	suite.Nil(loader.Load(c))
+	suite.Nil(c.err)
+	suite.Equal(&missing{
+		DB:  db{Host: "localhost"},
+		Ptr: &db{Host: "localhost"},
+	}, missingCfg)
+}
+
+func (suite *ConfigTestSuite) TestValidation() {
+	type nested struct {
+		Name string `config:"name,nonempty"`
+	}
+	type test struct {
+		Port     int           `config:"port,min=1,max=65535"`
+		Mode     string        `config:"mode,oneof=a|b|c"`
+		Host     string        `config:"host,regex=^[a-z]+\\.local$"`
+		Code     string        `config:"code,len=3"`
+		Timeout  time.Duration `config:"timeout,max=1s"`
+		Prefixes []string      `config:"prefixes,nonempty"`
+		Nested   []nested      `config:"nested"`
+	}
+
+	loader, err := NewLoader(suite.ctx,
+		file.New(file.WithPath(
+			suite.createFileForTest([]byte(`{"port":80,"mode":"a","host":"db.local","code":"abc",`+
+				`"timeout":1000,"prefixes":["0630"],"nested":[{"name":"a"}]}`)).Name(),
+		)),
+	)
+	suite.Nil(err)
+	c := &config{
+		structs: &test{},
+	}
+	err = loader.Load(c)
+	suite.Nil(err)
+	suite.Nil(c.err)
+
+	err = loader.AddSource(
+		file.New(file.WithPath(
+			suite.createFileForTest([]byte(`{"port":70000,"mode":"d","host":"db.remote","code":"ab",`+
+				`"timeout":2000000000,"prefixes":[],"nested":[{"name":"a"},{"name":""}]}`)).Name(),
+		), file.WithOption(backend.WithName("bad"))),
+	)
+	suite.Nil(err)
+	c = &config{
+		structs: &test{},
+	}
+	err = loader.Load(c)
+	suite.Nil(err)
+	var verrs ValidationErrors
+	suite.Require().True(errors.As(c.err, &verrs))
+	suite.Len(verrs, 7)
+	suite.Equal("port", verrs[0].Key)
+	suite.Equal("Port", verrs[0].Field)
+	suite.Equal("bad", verrs[0].Backend)
+	suite.Equal("max=65535", verrs[0].Rule)
+	suite.Equal(70000, verrs[0].Value)
+	suite.Equal("nested[1].name", verrs[6].Key)
+	suite.Equal("Nested[1].Name", verrs[6].Field)
+	suite.EqualError(verrs[1], "invalid value 'd' for key 'mode' (field 'Mode', backend 'bad'): must be one of a, b, c")
+
+	loader, err = NewLoader(suite.ctx,
+		file.New(file.WithPath(suite.createFileForTest([]byte(`{"p":1}`)).Name())),
+	)
+	suite.Nil(err)
+	c = &config{
+		structs: &struct {
+			P       int    `config:"p,min=10"`
+			Missing string `config:"missing,required,nonempty"`
+		}{},
+	}
+	suite.Nil(loader.Load(c))
+	var missing *MissingKeyError
+	suite.True(errors.As(c.err, &missing))
+	suite.Equal("missing", missing.Key)
+	verrs = nil
+	suite.Require().True(errors.As(c.err, &verrs))
+	suite.Len(verrs, 1)
+	suite.Equal("p", verrs[0].Key)
+}
+
+type poolConfig struct {
+	MinPool int `config:"min-pool"`
+	MaxPool int `config:"max-pool"`
+}
+
+func (p *poolConfig) Validate() error {
+	if p.MinPool > p.MaxPool {
+		return errors.New("min-pool must not be greater than max-pool")
+	}
+	return nil
+}
+
+type validatedConfig struct {
+	Pools []poolConfig `config:"pools"`
+	Name  string       `config:"name"`
+	calls *[]string
+}
+
+func (v validatedConfig) Validate() error {
+	*v.calls = append(*v.calls, "root")
+	if v.Name == "" {
+		return errors.New("name must be set")
+	}
+	return nil
+}
+
+func (suite *ConfigTestSuite) TestValidator() {
+	loader, err := NewLoader(suite.ctx,
+		file.New(file.WithPath(
+			suite.createFileForTest([]byte(`{"name":"name","pools":[{"min-pool":1,"max-pool":2}]}`)).Name(),
+		)),
+	)
+	suite.Nil(err)
+	var calls []string
+	c := &config{
+		structs: &validatedConfig{calls: &calls},
+	}
+	err = loader.Load(c)
+	suite.Nil(err)
+	suite.Nil(c.err)
+	suite.Equal([]string{"root"}, calls)
+
+	err = loader.AddSource(
+		file.New(file.WithPath(
+			suite.createFileForTest([]byte(`{"name":"","pools":[{"min-pool":1,"max-pool":2},{"min-pool":3,"max-pool":2}]}`)).Name(),
+		)),
+	)
+	suite.Nil(err)
+	calls = nil
+	c = &config{
+		structs: &validatedConfig{calls: &calls},
+	}
+	err = loader.Load(c)
+	suite.Nil(err)
+	var verr *ValidatorError
+	suite.Require().True(errors.As(c.err, &verr))
+	suite.Equal("Pools[1]", verr.Path)
+	suite.EqualError(c.err, "validation failed for 'Pools[1]': min-pool must not be greater than max-pool")
+	suite.Empty(calls)
+}
+
 func (suite *ConfigTestSuite) TestTagsBadRequired() {
 	type test struct {
 		Key string `config:"key,rrequiredd,backend=store"`
@@ -412,6 +950,196 @@ func (suite *ConfigTestSuite) TestNestedRequired() {
 	}, nst)
 }
 
+func (suite *ConfigTestSuite) TestNestedMerge() {
+	type params struct {
+		Record   int    `config:"record"`
+		Filepath string `config:"filepath"`
+	}
+	type nested struct {
+		Active    bool     `config:"active"`
+		Prefixes  []string `config:"prefixes"`
+		AppParams *params  `config:"app-params"`
+	}
+	type test struct {
+		Key     string  `config:"key"`
+		Nested  *nested `config:"nested"`
+		Created *nested `config:"created"`
+	}
+
+	loader, err := NewLoader(suite.ctx,
+		file.New(file.WithPath(
+			suite.createFileForTest([]byte(`{"key":"base","nested":{"active":false,"prefixes":["0630"],`+
+				`"app-params":{"record":1,"filepath":"path"}},"created":{"active":true}}`)).Name(),
+		)),
+		file.New(file.WithPath(
+			suite.createFileForTest([]byte(`
+nested:
+  active: true
+  app-params:
+    record: 2
+created:
+  app-params:
+    filepath: created
+`)).Name(),
+		), file.WithOption(backend.WithEncoder(yaml.New()))),
+	)
+	suite.Nil(err)
+	nst := &nested{}
+	cfg := &test{
+		Nested: nst,
+	}
+	c := &config{
+		structs: cfg,
+	}
+	err = loader.Load(c)
+	suite.Nil(err)
+	suite.Nil(c.err)
+	suite.Equal("base", cfg.Key)
+	suite.Equal(&nested{
+		Active:   true,
+		Prefixes: []string{"0630"},
+		AppParams: &params{
+			Record:   2,
+			Filepath: "path",
+		},
+	}, nst)
+	suite.Equal(&nested{
+		Active: true,
+		AppParams: &params{
+			Filepath: "created",
+		},
+	}, cfg.Created)
+}
+
+func (suite *ConfigTestSuite) TestDottedKeys() {
+	type test struct {
+		Host    string   `config:"database.primary.host"`
+		Port    int      `config:"database.primary.port,default=5432"`
+		Users   []string `config:"database.users"`
+		Literal string   `config:"literal.key"`
+		Missing string   `config:"database.replica.host"`
+	}
+	sources := []backend.Backend{
+		file.New(file.WithPath(
+			suite.createFileForTest([]byte(`{"database":{"primary":{"host":"json"},"users":["a"]},"literal.key":"json"}`)).Name(),
+		)),
+		file.New(file.WithPath(
+			suite.createFileForTest([]byte(`
+database:
+  primary:
+    host: yaml
+  users: [a]
+literal.key: yaml
+`)).Name(),
+		), file.WithOption(backend.WithEncoder(yaml.New()))),
+		file.New(file.WithPath(
+			suite.createFileForTest([]byte(`
+"literal.key" = "toml"
+[database]
+users = ["a"]
+[database.primary]
+host = "toml"
+`)).Name(),
+		), file.WithOption(backend.WithEncoder(toml.New()))),
+	}
+	for _, s := range sources {
+		loader, err := NewLoader(suite.ctx, s)
+		suite.Nil(err)
+		cfg := &test{}
+		c := &config{
+			structs: cfg,
+		}
+		suite.Nil(loader.Load(c))
+		suite.Nil(c.err)
+		name := cfg.Literal
+		suite.Equal(&test{
+			Host:    name,
+			Port:    5432,
+			Users:   []string{"a"},
+			Literal: name,
+		}, cfg)
+		suite.NotEmpty(name)
+
+		b, err := loader.Dump(c, json.New())
+		suite.Nil(err)
+		suite.JSONEq(`{"database":{"primary":{"host":"`+name+`","port":5432},"replica":{"host":""},"users":["a"]},`+
+			`"literal":{"key":"`+name+`"}}`, string(b))
+	}
+}
+
+func (suite *ConfigTestSuite) TestMap() {
+	type upstream struct {
+		Host    string `config:"host"`
+		Port    int    `config:"port,default=80"`
+		Enabled bool   `config:"enabled"`
+	}
+	type test struct {
+		Upstreams map[string]upstream  `config:"upstreams"`
+		Pointers  map[string]*upstream `config:"pointers"`
+		Limits    map[string]int       `config:"limits"`
+		Secrets   map[string]string    `config:"secrets,secret"`
+	}
+
+	loader, err := NewLoader(suite.ctx,
+		file.New(file.WithPath(
+			suite.createFileForTest([]byte(`{"upstreams":{"api":{"host":"api.local","port":8080},"db":{"host":"db.local"}},`+
+				`"pointers":{"api":{"host":"api.local"}},"limits":{"a":1,"b":2},"secrets":{"token":"token"}}`)).Name(),
+		)),
+		file.New(file.WithPath(
+			suite.createFileForTest([]byte(`
+upstreams:
+  api:
+    enabled: true
+  cache:
+    host: cache.local
+pointers:
+  api:
+    port: 81
+limits:
+  b: 3
+`)).Name(),
+		), file.WithOption(backend.WithEncoder(yaml.New()))),
+	)
+	suite.Nil(err)
+	cfg := &test{
+		Limits: map[string]int{"c": 4},
+	}
+	c := &config{
+		structs: cfg,
+	}
+	suite.Nil(loader.Load(c))
+	suite.Nil(c.err)
+	suite.Equal(&test{
+		Upstreams: map[string]upstream{
+			"api":   {Host: "api.local", Port: 8080, Enabled: true},
+			"cache": {Host: "cache.local", Port: 80},
+			"db":    {Host: "db.local", Port: 80},
+		},
+		Pointers: map[string]*upstream{
+			"api": {Host: "api.local", Port: 81},
+		},
+		Limits:  map[string]int{"a": 1, "b": 3, "c": 4},
+		Secrets: map[string]string{"token": "token"},
+	}, cfg)
+
+	var keys []string
+	for _, p := range loader.Explain(c) {
+		keys = append(keys, p.Key+"="+p.Backend)
+	}
+	suite.Contains(keys, "upstreams.api.enabled=file")
+	suite.Contains(keys, "upstreams.api.port=file")
+	suite.Contains(keys, "pointers.api.port=file")
+	suite.Contains(keys, "limits.c=")
+
+	b, err := loader.Dump(c, json.New())
+	suite.Nil(err)
+	suite.JSONEq(`{"secrets":{"token":"******"},"limits":{"a":1,"b":3,"c":4},`+
+		`"pointers":{"api":{"enabled":false,"host":"api.local","port":81}},"upstreams":{`+
+		`"api":{"enabled":true,"host":"api.local","port":8080},`+
+		`"cache":{"enabled":false,"host":"cache.local","port":80},`+
+		`"db":{"enabled":false,"host":"db.local","port":80}}}`, string(b))
+}
+
 func (suite *ConfigTestSuite) TestNestedYaml() {
 	type nested struct {
 		Key string `config:"key"`
@@ -627,20 +1355,696 @@ func (suite *ConfigTestSuite) TestWatch() {
 	suite.Equal("name2", s.Name)
 }
 
-func (suite *ConfigTestSuite) TestArray() {
-	type nested2 struct {
-		StringName string `config:"strings"`
+func (suite *ConfigTestSuite) TestReloadPolicy() {
+	type test struct {
+		Name string `config:"name,required"`
+		Age  int    `config:"age,required"`
 	}
-	type nested struct {
-		NestedInt  int      `config:"ints"`
-		IntPointer *int     `config:"intpointer"`
-		Nested     *nested2 `config:"nes"`
+	for _, policy := range []ReloadPolicy{ReloadApply, ReloadKeepLastGood, ReloadFailFast} {
+		loader, err := NewLoader(suite.ctx)
+		suite.Nil(err)
+		loader.SetOptions(WithReloadPolicy(policy))
+		var reported []error
+		var reportedMu sync.Mutex
+		loader.OnError(func(err error) {
+			reportedMu.Lock()
+			defer reportedMu.Unlock()
+			reported = append(reported, err)
+		})
+		f := suite.createFileForTest([]byte(`{"name":"name","age":10}`))
+		err = loader.AddSource(
+			file.New(file.WithPath(
+				f.Name(),
+			), file.WithWatchInterval(100*time.Millisecond),
+				file.WithOption(backend.WithWatcher())),
+		)
+		suite.Nil(err)
+		c := &freshConfig{
+			newSnapshot: func() interface{} {
+				return &test{}
+			},
+		}
+		err = loader.Load(c)
+		suite.Nil(err)
+		suite.Nil(c.err)
+		suite.Equal(&test{Name: "name", Age: 10}, c.structs)
+
+		suite.updateFileForTest(f, []byte(`{"name":"name2"}`))
+		suite.Eventually(func() bool {
+			rejected, _ := loader.Rejected(c)
+			return c.calls() == 2 || rejected != nil
+		}, 3*time.Second, 50*time.Millisecond)
+		if policy != ReloadApply {
+			suite.Eventually(func() bool {
+				reportedMu.Lock()
+				defer reportedMu.Unlock()
+				return len(reported) > 0
+			}, 3*time.Second, 50*time.Millisecond)
+		}
+		rejected, rejectedErr := loader.Rejected(c)
+		c.Lock()
+		reportedMu.Lock()
+		switch policy {
+		case ReloadApply:
+			suite.Nil(rejected)
+			suite.Equal(&test{Name: "name2"}, c.structs)
+			suite.EqualError(c.err, "required key 'age' for field 'Age' not found")
+			suite.Empty(reported)
+		default:
+			suite.Equal(&test{Name: "name2"}, rejected)
+			suite.EqualError(rejectedErr, "required key 'age' for field 'Age' not found")
+			suite.Equal(&test{Name: "name", Age: 10}, c.structs)
+			suite.Nil(c.err)
+			suite.Require().Len(reported, 1)
+			var werr *WatchError
+			suite.Require().True(errors.As(reported[0], &werr))
+			suite.Equal("file", werr.Backend)
+			var missing *MissingKeyError
+			suite.True(errors.As(werr, &missing))
+			suite.Equal(policy == ReloadFailFast, errors.Is(werr, ErrClosed))
+		}
+		reportedMu.Unlock()
+		c.Unlock()
+
+		suite.updateFileForTest(f, []byte(`{"name":"name3","age":12}`))
+		if policy == ReloadFailFast {
+			time.Sleep(500 * time.Millisecond)
+			suite.Equal(1, c.calls())
+			suite.True(errors.Is(loader.Reload(context.Background()), ErrClosed))
+			continue
+		}
+		suite.Eventually(func() bool {
+			c.Lock()
+			defer c.Unlock()
+			return c.err == nil && c.structs.(*test).Name == "name3"
+		}, 3*time.Second, 50*time.Millisecond)
+		rejected, rejectedErr = loader.Rejected(c)
+		suite.Nil(rejected)
+		suite.Nil(rejectedErr)
 	}
+}
 
-	s := &struct {
-		ModuleName *string  `config:"module,required"`
-		Int        []nested `config:"int,required"`
-	}{}
+func (suite *ConfigTestSuite) TestOnChange() {
+	type nested struct {
+		Key string `config:"key"`
+	}
+	type test struct {
+		Name   string   `config:"name"`
+		Age    int      `config:"age"`
+		Tags   []string `config:"tags"`
+		Nested *nested  `config:"nested"`
+	}
+	loader, err := NewLoader(suite.ctx)
+	suite.Nil(err)
+	f := suite.createFileForTest([]byte(`{"name":"name","age":10,"tags":["a"],"nested":{"key":"key"}}`))
+	err = loader.AddSource(
+		file.New(file.WithPath(
+			f.Name(),
+		), file.WithWatchInterval(50*time.Millisecond),
+			file.WithOption(backend.WithWatcher()),
+			file.WithOption(backend.WithName("watched"))),
+	)
+	suite.Nil(err)
+	changed := make(chan []Change, 10)
+	c := &freshConfig{
+		newSnapshot: func() interface{} {
+			return &test{}
+		},
+	}
+	loader.OnChange(func(cfg Config, changes []Change) {
+		suite.Equal(c, cfg)
+		changed <- changes
+	})
+	suite.Nil(loader.Load(c))
+	suite.Nil(c.err)
+
+	suite.updateFileForTest(f, []byte(`{"name":"name","age":11,"tags":["a","b"]}`))
+	select {
+	case changes := <-changed:
+		suite.Equal([]Change{
+			{Key: "age", Old: 10, New: 11, Backend: "watched"},
+			{Key: "nested.key", Old: "key", New: nil, Backend: "watched"},
+			{Key: "tags", Old: []string{"a"}, New: []string{"a", "b"}, Backend: "watched"},
+		}, changes)
+	case <-time.After(3 * time.Second):
+		suite.Fail("change not notified")
+	}
+
+	suite.updateFileForTest(f, []byte(`{"name":"name","age":11,"tags":["a","b"]} `))
+	select {
+	case changes := <-changed:
+		suite.Fail("unexpected change", "%v", changes)
+	case <-time.After(500 * time.Millisecond):
+	}
+}
+
+func (suite *ConfigTestSuite) TestStore() {
+	type test struct {
+		Name string `config:"name"`
+		Age  int    `config:"age"`
+		Role string `config:"role"`
+	}
+	loader, err := NewLoader(suite.ctx)
+	suite.Nil(err)
+	f := suite.createFileForTest([]byte(`{"name":"name","age":10}`))
+	err = loader.AddSource(
+		file.New(file.WithPath(
+			f.Name(),
+		), file.WithWatchInterval(50*time.Millisecond),
+			file.WithOption(backend.WithWatcher()),
+			file.WithOption(backend.WithName("watched"))),
+	)
+	suite.Nil(err)
+	store, err := Bind(loader, func() *test {
+		return &test{Role: "user"}
+	})
+	suite.Require().Nil(err)
+	suite.Equal(&test{Name: "name", Age: 10, Role: "user"}, store.Get())
+	suite.Nil(store.Err())
+	suite.Equal("watched", loader.Explain(store.Config())[0].Backend)
+
+	type update struct {
+		old, new *test
+	}
+	updates := make(chan update, 10)
+	store.Subscribe(func(old, new *test) {
+		updates <- update{old, new}
+	})
+	first := store.Get()
+	suite.updateFileForTest(f, []byte(`{"name":"name","age":11}`))
+	select {
+	case u := <-updates:
+		suite.Same(first, u.old)
+		suite.Equal(&test{Name: "name", Age: 11, Role: "user"}, u.new)
+		suite.Same(u.new, store.Get())
+	case <-time.After(3 * time.Second):
+		suite.Fail("change not notified")
+	}
+	suite.Equal(&test{Name: "name", Age: 10, Role: "user"}, first)
+
+	_, err = Bind[struct {
+		Name string `config:"missing,required"`
+	}](loader, nil)
+	suite.Error(err)
+	suite.Contains(err.Error(), "required key 'missing'")
+	suite.Len(loader.backendWatcher, 1)
+	suite.Len(loader.onChange, 1)
+
+	last := store.Get()
+	suite.Nil(store.Close())
+	suite.Empty(loader.backendWatcher)
+	suite.True(errors.Is(store.Close(), ErrNotLoaded))
+	suite.updateFileForTest(f, []byte(`{"name":"name","age":12}`))
+	suite.Nil(loader.Reload(context.Background()))
+	select {
+	case <-updates:
+		suite.Fail("closed store notified")
+	case <-time.After(300 * time.Millisecond):
+	}
+	suite.Same(last, store.Get())
+}
+
+type reentrantConfig struct {
+	loader     *Loader
+	provenance []Provenance
+}
+
+func (c *reentrantConfig) NewSnapshot() interface{} {
+	return &struct {
+		Name string `config:"name"`
+	}{}
+}
+
+func (c *reentrantConfig) SetSnapshot(interface{}, error) {
+	c.provenance = c.loader.Explain(c)
+	c.loader.Rejected(c)
+}
+
+func (suite *ConfigTestSuite) TestSetSnapshotCallsLoader() {
+	loader, err := NewLoader(suite.ctx, file.New(file.WithPath(
+		suite.createFileForTest([]byte(`{"name":"name"}`)).Name(),
+	)))
+	suite.Require().Nil(err)
+	c := &reentrantConfig{loader: loader}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		suite.Nil(loader.Load(c))
+		suite.Nil(loader.Reload(context.Background()))
+	}()
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		suite.FailNow("SetSnapshot calling the loader deadlocked")
+	}
+	suite.Require().Len(c.provenance, 1)
+	suite.Equal("name", c.provenance[0].Key)
+	suite.Equal("file", c.provenance[0].Backend)
+}
+
+func (suite *ConfigTestSuite) TestWatcherErrorsDoNotBlock() {
+	f := suite.createFileForTest([]byte(`{"name":"name"}`))
+	w, err := file.New(file.WithPath(f.Name()),
+		file.WithWatchInterval(20*time.Millisecond),
+		file.WithOption(backend.WithWatcher()),
+	).Watcher()
+	suite.Require().Nil(err)
+	defer w.Stop()
+	ch := w.Watch()
+	// nobody reads the errors of the watcher
+	for i := 0; i < 20; i++ {
+		suite.updateFileForTest(f, []byte(fmt.Sprintf(`{"name":%d`, i)))
+		time.Sleep(30 * time.Millisecond)
+	}
+	suite.updateFileForTest(f, []byte(`{"name":"fixed"}`))
+	select {
+	case c := <-ch:
+		suite.Contains(c.Data, "name")
+	case <-time.After(3 * time.Second):
+		suite.Fail("watcher blocked by its errors")
+	}
+}
+
+func (suite *ConfigTestSuite) TestOnError() {
+	s := &struct {
+		Name string `config:"name"`
+	}{}
+	loader, err := NewLoader(suite.ctx)
+	suite.Nil(err)
+	f := suite.createFileForTest([]byte(`{"name":"name"}`))
+	err = loader.AddSource(
+		file.New(file.WithPath(
+			f.Name(),
+		), file.WithWatchInterval(50*time.Millisecond),
+			file.WithOption(backend.WithWatcher())),
+	)
+	suite.Nil(err)
+	errs := make(chan error, 10)
+	loader.OnError(func(err error) {
+		errs <- err
+	})
+	c := &config{
+		structs: s,
+	}
+	suite.Nil(loader.Load(c))
+
+	suite.updateFileForTest(f, []byte(`{"name":`))
+	select {
+	case err := <-errs:
+		var werr *WatchError
+		suite.Require().True(errors.As(err, &werr))
+		suite.Equal("file", werr.Backend)
+		suite.Error(werr.Err)
+	case <-time.After(3 * time.Second):
+		suite.Fail("error not reported")
+	}
+	c.Lock()
+	defer c.Unlock()
+	suite.Equal("name", s.Name)
+}
+
+func (suite *ConfigTestSuite) TestReload() {
+	type test struct {
+		Name string `config:"name,required"`
+		Age  int    `config:"age"`
+	}
+	suite.Nil(os.Setenv("RELOAD_NAME", "name"))
+	defer os.Unsetenv("RELOAD_NAME")
+	f := suite.createFileForTest([]byte(`{"age":10}`))
+	loader, err := NewLoader(suite.ctx,
+		file.New(file.WithPath(f.Name())),
+		env.New(env.WithStripPrefix("RELOAD_")),
+	)
+	suite.Nil(err)
+	cfg := &test{}
+	c := &config{
+		structs: cfg,
+	}
+	suite.Nil(loader.Load(c))
+	suite.Nil(c.err)
+	suite.Equal(&test{Name: "name", Age: 10}, cfg)
+
+	suite.Nil(os.Setenv("RELOAD_NAME", "name2"))
+	suite.updateFileForTest(f, []byte(`{"age":11}`))
+	suite.Nil(loader.Reload(context.Background()))
+	suite.Nil(c.err)
+	suite.Equal(&test{Name: "name2", Age: 11}, cfg)
+
+	suite.updateFileForTest(f, []byte(`{"age":`))
+	suite.Error(loader.Reload(context.Background()))
+	suite.Equal(&test{Name: "name2", Age: 11}, cfg)
+
+	suite.Nil(os.Setenv("RELOAD_NAME", "name3"))
+	process, err := os.FindProcess(os.Getpid())
+	suite.Nil(err)
+	reloaded := make(chan struct{}, 1)
+	loader.OnChange(func(Config, []Change) {
+		reloaded <- struct{}{}
+	})
+	loader.ReloadOnSignal()
+	suite.Nil(process.Signal(syscall.SIGHUP))
+	select {
+	case <-reloaded:
+		c.Lock()
+		suite.Equal("name3", cfg.Name)
+		c.Unlock()
+	case <-time.After(3 * time.Second):
+		suite.Fail("not reloaded on SIGHUP")
+	}
+	suite.Nil(loader.Close())
+	suite.Error(loader.Reload(context.Background()))
+}
+
+func (suite *ConfigTestSuite) TestRemoveSourceAndUnload() {
+	type test struct {
+		Name string `config:"name"`
+		Age  int    `config:"age"`
+	}
+	base := file.New(file.WithPath(
+		suite.createFileForTest([]byte(`{"name":"base","age":10}`)).Name(),
+	))
+	f := suite.createFileForTest([]byte(`{"name":"override"}`))
+	override := file.New(file.WithPath(f.Name()),
+		file.WithWatchInterval(50*time.Millisecond),
+		file.WithOption(backend.WithWatcher()),
+	)
+	loader, err := NewLoader(suite.ctx, base, override)
+	suite.Require().Nil(err)
+	c := &freshConfig{
+		newSnapshot: func() interface{} {
+			return &test{}
+		},
+	}
+	unloaded := &freshConfig{
+		newSnapshot: func() interface{} {
+			return &test{}
+		},
+	}
+	changed := make(chan []Change, 10)
+	loader.OnChange(func(cfg Config, changes []Change) {
+		changed <- changes
+	})
+	suite.Nil(loader.Load(c))
+	suite.Nil(loader.Load(unloaded))
+	suite.Equal(&test{Name: "override", Age: 10}, c.structs)
+
+	suite.Nil(loader.Unload(unloaded))
+	suite.Error(loader.Unload(unloaded))
+	suite.Nil(loader.RemoveSource(override))
+	suite.Error(loader.RemoveSource(override))
+	suite.Nil(c.err)
+	suite.Equal(&test{Name: "base", Age: 10}, c.structs)
+	suite.Equal([]Change{{Key: "name", Old: "override", New: "base", Backend: base.String()}}, <-changed)
+	suite.Equal(1, unloaded.calls())
+
+	suite.updateFileForTest(f, []byte(`{"name":"updated"}`))
+	select {
+	case changes := <-changed:
+		suite.Fail("removed source still watched", "%v", changes)
+	case <-time.After(300 * time.Millisecond):
+	}
+	suite.Nil(loader.Reload(context.Background()))
+	suite.Equal(&test{Name: "base", Age: 10}, c.structs)
+	suite.Equal(1, unloaded.calls())
+}
+
+func (suite *ConfigTestSuite) TestNaming() {
+	type nested struct {
+		HTTPServer string
+	}
+	type Embedded struct {
+		Debug bool
+	}
+	type test struct {
+		Embedded
+		RecallCheckInterval int
+		SentryDSN           string `config:",secret"`
+		Amd2Config          *nested
+		Tagged              string `config:"other"`
+	}
+	tests := []struct {
+		naming NamingStrategy
+		data   string
+	}{
+		{NamingExact, `{"Debug":true,"RecallCheckInterval":1,"SentryDSN":"dsn","Amd2Config":{"HTTPServer":"srv"},"other":"tag"}`},
+		{NamingKebab, `{"debug":true,"recall-check-interval":1,"sentry-dsn":"dsn","amd2-config":{"http-server":"srv"},"other":"tag"}`},
+		{NamingSnake, `{"debug":true,"recall_check_interval":1,"sentry_dsn":"dsn","amd2_config":{"http_server":"srv"},"other":"tag"}`},
+		{NamingLowerCamel, `{"debug":true,"recallCheckInterval":1,"sentryDSN":"dsn","amd2Config":{"httpServer":"srv"},"other":"tag"}`},
+	}
+	expected := &test{
+		Embedded:            Embedded{Debug: true},
+		RecallCheckInterval: 1,
+		SentryDSN:           "dsn",
+		Amd2Config:          &nested{HTTPServer: "srv"},
+		Tagged:              "tag",
+	}
+	for _, tt := range tests {
+		loader, err := NewLoader(suite.ctx, file.New(file.WithPath(
+			suite.createFileForTest([]byte(tt.data)).Name(),
+		)))
+		suite.Require().Nil(err)
+		loader.SetOptions(WithNaming(tt.naming))
+		cfg := &test{}
+		c := &config{
+			structs: cfg,
+		}
+		suite.Nil(loader.Load(c))
+		suite.Nil(c.err)
+		suite.Equal(expected, cfg, "naming %d", tt.naming)
+	}
+
+	loader, err := NewLoader(suite.ctx, file.New(file.WithPath(
+		suite.createFileForTest([]byte(`{"recallcheckinterval":1,"SENTRYDSN":"dsn","amd2config":{"HttpServer":"srv"}}`)).Name(),
+	)))
+	suite.Require().Nil(err)
+	loader.SetOptions(WithNaming(NamingExact), WithCaseInsensitiveKeys())
+	cfg := &test{}
+	c := &config{
+		structs: cfg,
+	}
+	suite.Nil(loader.Load(c))
+	suite.Nil(c.err)
+	suite.Equal(&test{RecallCheckInterval: 1, SentryDSN: "dsn", Amd2Config: &nested{HTTPServer: "srv"}}, cfg)
+
+	type dotted struct {
+		Key string `config:"nested.key"`
+	}
+	loader, err = NewLoader(suite.ctx, file.New(file.WithPath(
+		suite.createFileForTest([]byte(`{"Nested":{"KEY":"value"}}`)).Name(),
+	)))
+	suite.Require().Nil(err)
+	loader.SetOptions(WithCaseInsensitiveKeys())
+	d := &dotted{}
+	suite.Nil(loader.Load(&config{structs: d}))
+	suite.Equal("value", d.Key)
+}
+
+func (suite *ConfigTestSuite) TestExplain() {
+	type nested struct {
+		Key string `config:"key"`
+	}
+	type test struct {
+		Name    string   `config:"name"`
+		Timeout int      `config:"timeout,default=30"`
+		Env     string   `config:"env"`
+		Missing string   `config:"missing"`
+		Nested  *nested  `config:"nested"`
+		List    []nested `config:"list"`
+	}
+	suite.Nil(os.Setenv("EXPLAIN_ENV", "env"))
+	defer os.Unsetenv("EXPLAIN_ENV")
+	loader, err := NewLoader(suite.ctx,
+		file.New(file.WithPath(
+			suite.createFileForTest([]byte(`{"name":"name","nested":{"key":"key"},"list":[{"key":"a"}]}`)).Name(),
+		)),
+		env.New(env.WithStripPrefix("EXPLAIN_")),
+	)
+	suite.Nil(err)
+	c := &config{
+		structs: &test{},
+	}
+	suite.Nil(loader.Load(c))
+	suite.Nil(c.err)
+
+	list := loader.Explain(c)
+	suite.Len(list, 6)
+	for i := range list {
+		suite.Equal(list[i].Found, !list[i].Timestamp.IsZero() || list[i].Default)
+		list[i].Timestamp = time.Time{}
+	}
+	suite.Equal([]Provenance{
+		{Key: "name", Field: "Name", Found: true, Backend: "file", Source: "file"},
+		{Key: "timeout", Field: "Timeout", Found: true, Default: true, Backend: "default", Source: "default"},
+		{Key: "env", Field: "Env", Found: true, Backend: "env", Source: "env"},
+		{Key: "missing", Field: "Missing"},
+		{Key: "nested.key", Field: "Nested.Key", Found: true, Backend: "file", Source: "file"},
+		{Key: "list[0].key", Field: "List[0].Key", Found: true, Backend: "file", Source: "file"},
+	}, list)
+	suite.Nil(loader.Explain(&config{}))
+}
+
+func (suite *ConfigTestSuite) TestDump() {
+	type database struct {
+		User     string `config:"user"`
+		Password string `config:"password,secret"`
+	}
+	type test struct {
+		Name     string     `config:"name"`
+		Token    string     `config:"token,secret"`
+		Empty    string     `config:"empty,secret"`
+		Database *database  `config:"database"`
+		Replicas []database `config:"replicas"`
+		Missing  *database  `config:"missing"`
+	}
+	loader, err := NewLoader(suite.ctx,
+		file.New(file.WithPath(
+			suite.createFileForTest([]byte(`{"name":"name","token":"token","database":{"user":"user","password":"pass"},`+
+				`"replicas":[{"user":"replica","password":"pass"}]}`)).Name(),
+		)),
+	)
+	suite.Nil(err)
+	c := &config{
+		structs: &test{},
+	}
+	_, err = loader.Dump(c, json.New())
+	suite.Error(err)
+	suite.Nil(loader.Load(c))
+	suite.Nil(c.err)
+
+	b, err := loader.Dump(c, json.New())
+	suite.Nil(err)
+	suite.JSONEq(`{"name":"name","token":"******","empty":"","database":{"user":"user","password":"******"},`+
+		`"replicas":[{"user":"replica","password":"******"}]}`, string(b))
+
+	b, err = loader.Dump(c, yaml.New())
+	suite.Nil(err)
+	suite.YAMLEq(`
+name: name
+token: "******"
+empty: ""
+database:
+  user: user
+  password: "******"
+replicas:
+  - user: replica
+    password: "******"
+`, string(b))
+
+	b, err = loader.Dump(c, toml.New())
+	suite.Nil(err)
+	suite.Contains(string(b), `password = "******"`)
+	suite.NotContains(string(b), "pass\"")
+}
+
+func (suite *ConfigTestSuite) TestDumpSecretSubtree() {
+	type cred struct {
+		Pass string `config:"pass"`
+	}
+	type test struct {
+		DB   cred            `config:"db,secret"`
+		Ptr  *cred           `config:"ptr,secret"`
+		List []cred          `config:"list,secret"`
+		Map  map[string]cred `config:"map,secret"`
+	}
+	loader, err := NewLoader(suite.ctx,
+		file.New(file.WithPath(
+			suite.createFileForTest([]byte(`{"db":{"pass":"hunter2"},"ptr":{"pass":"p1"},`+
+				`"list":[{"pass":"x1"}],"map":{"a":{"pass":"m1"}}}`)).Name(),
+		)),
+	)
+	suite.Nil(err)
+	c := &config{
+		structs: &test{},
+	}
+	suite.Nil(loader.Load(c))
+	suite.Nil(c.err)
+
+	b, err := loader.Dump(c, json.New())
+	suite.Nil(err)
+	suite.JSONEq(`{"db":{"pass":"******"},"ptr":{"pass":"******"},"list":[{"pass":"******"}],`+
+		`"map":{"a":{"pass":"******"}}}`, string(b))
+}
+
+func (suite *ConfigTestSuite) TestNoWatcherNoGoroutine() {
+	defer goleak.VerifyNone(suite.T(), goleak.IgnoreCurrent())
+
+	loader, err := NewLoader(context.Background(),
+		file.New(file.WithPath(
+			suite.createFileForTest([]byte(`{"name":"name"}`)).Name(),
+		)),
+	)
+	suite.Nil(err)
+	c := &config{
+		structs: &struct {
+			Name string `config:"name"`
+		}{},
+	}
+	suite.Nil(loader.Load(c))
+	suite.Nil(c.err)
+}
+
+func (suite *ConfigTestSuite) TestClose() {
+	defer goleak.VerifyNone(suite.T(), goleak.IgnoreCurrent())
+
+	ctx, cancel := context.WithCancel(suite.ctx)
+	defer cancel()
+	loader, err := NewLoader(ctx,
+		file.New(file.WithPath(
+			suite.createFileForTest([]byte(`{"name":"name"}`)).Name(),
+		), file.WithWatchInterval(10*time.Millisecond),
+			file.WithOption(backend.WithWatcher())),
+		env.New(env.WithDefaults(
+			suite.createFileForTest([]byte(`AGE=10`)).Name(),
+		), env.WithWatchInterval(10*time.Millisecond),
+			env.WithOption(backend.WithWatcher())),
+	)
+	suite.Nil(err)
+	c := &config{
+		structs: &struct {
+			Name string `config:"name"`
+			Age  int    `config:"age"`
+		}{},
+	}
+	suite.Nil(loader.Load(c))
+	suite.Nil(c.err)
+	suite.Nil(loader.Close())
+	suite.Nil(loader.Close())
+	suite.NotNil(loader.AddSource(
+		file.New(file.WithPath(
+			suite.createFileForTest([]byte(`{}`)).Name(),
+		), file.WithOption(backend.WithWatcher())),
+	))
+}
+
+func (suite *ConfigTestSuite) TestCloseOnContextCancel() {
+	defer goleak.VerifyNone(suite.T(), goleak.IgnoreCurrent())
+
+	ctx, cancel := context.WithCancel(suite.ctx)
+	loader, err := NewLoader(ctx,
+		file.New(file.WithPath(
+			suite.createFileForTest([]byte(`{"name":"name"}`)).Name(),
+		), file.WithWatchInterval(10*time.Millisecond),
+			file.WithOption(backend.WithWatcher())),
+	)
+	suite.Nil(err)
+	cancel()
+	suite.Nil(loader.Close())
+}
+
+func (suite *ConfigTestSuite) TestArray() {
+	type nested2 struct {
+		StringName string `config:"strings"`
+	}
+	type nested struct {
+		NestedInt  int      `config:"ints"`
+		IntPointer *int     `config:"intpointer"`
+		Nested     *nested2 `config:"nes"`
+	}
+
+	s := &struct {
+		ModuleName *string  `config:"module,required"`
+		Int        []nested `config:"int,required"`
+	}{}
 	loader, err := NewLoader(suite.ctx)
 	suite.Nil(err)
 	f := suite.createFileForTest([]byte(`{"module":"name","int":[{"ints":10,"intpointer": 10,"nes":{"strings":"asd"}}, {"ints":11,"nes":{"strings":"qwe"}}]}`))
@@ -660,6 +2064,517 @@ func (suite *ConfigTestSuite) TestArray() {
 	suite.Equal("qwe", s.Int[1].Nested.StringName)
 }
 
+func (suite *ConfigTestSuite) TestCollections() {
+	type child struct {
+		Key string `config:"key"`
+	}
+	type item struct {
+		Name     string  `config:"name"`
+		Children []child `config:"children"`
+	}
+	type test struct {
+		Pointers []*item           `config:"pointers"`
+		Matrix   [][]item          `config:"matrix"`
+		Array    [2]item           `config:"array"`
+		Nested   []item            `config:"nested"`
+		Maps     []map[string]item `config:"maps"`
+	}
+	expected := &test{
+		Pointers: []*item{{Name: "a"}, nil, {Name: "b"}},
+		Matrix:   [][]item{{{Name: "a"}, {Name: "b"}}, {{Name: "c"}}},
+		Array:    [2]item{{Name: "a"}},
+		Nested: []item{
+			{Name: "a", Children: []child{{Key: "a1"}, {Key: "a2"}}},
+			{Name: "b"},
+		},
+		Maps: []map[string]item{{"x": {Name: "x"}}},
+	}
+
+	tests := []struct {
+		name    string
+		encoder encoder.Encoder
+		content string
+	}{
+		{
+			name:    "json",
+			encoder: json.New(),
+			content: `{"pointers":[{"name":"a"},null,{"name":"b"}],` +
+				`"matrix":[[{"name":"a"},{"name":"b"}],[{"name":"c"}]],` +
+				`"array":[{"name":"a"}],` +
+				`"nested":[{"name":"a","children":[{"key":"a1"},{"key":"a2"}]},{"name":"b"}],` +
+				`"maps":[{"x":{"name":"x"}}]}`,
+		},
+		{
+			name:    "yaml",
+			encoder: yaml.New(),
+			content: `
+pointers:
+  - name: a
+  - null
+  - name: b
+matrix:
+  - - name: a
+    - name: b
+  - - name: c
+array:
+  - name: a
+nested:
+  - name: a
+    children:
+      - key: a1
+      - key: a2
+  - name: b
+maps:
+  - x:
+      name: x
+`,
+		},
+		{
+			name:    "toml",
+			encoder: toml.New(),
+			content: `
+pointers = [{name = "a"}, {}, {name = "b"}]
+matrix = [[{name = "a"}, {name = "b"}], [{name = "c"}]]
+array = [{name = "a"}]
+maps = [{x = {name = "x"}}]
+
+[[nested]]
+name = "a"
+children = [{key = "a1"}, {key = "a2"}]
+
+[[nested]]
+name = "b"
+`,
+		},
+	}
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			loader, err := NewLoader(suite.ctx,
+				file.New(file.WithPath(
+					suite.createFileForTest([]byte(tt.content)).Name(),
+				), file.WithOption(backend.WithEncoder(tt.encoder))),
+			)
+			suite.Require().Nil(err)
+			cfg := &test{}
+			c := &config{
+				structs: cfg,
+			}
+			suite.Nil(loader.Load(c))
+			suite.Nil(c.err)
+			want := *expected
+			if tt.name == "toml" {
+				// toml has no null, the empty table gives an empty struct
+				want.Pointers = []*item{{Name: "a"}, {}, {Name: "b"}}
+			}
+			suite.Equal(&want, cfg)
+		})
+	}
+
+	loader, err := NewLoader(suite.ctx,
+		file.New(file.WithPath(
+			suite.createFileForTest([]byte(`{"array":[{"name":"a"},{"name":"b"},{"name":"c"}]}`)).Name(),
+		)),
+	)
+	suite.Nil(err)
+	c := &config{
+		structs: &test{},
+	}
+	suite.Nil(loader.Load(c))
+	suite.Error(c.err)
+}
+
+func (suite *ConfigTestSuite) TestDurationAndByteSize() {
+	type test struct {
+		Human    time.Duration  `config:"human"`
+		Seconds  time.Duration  `config:"seconds,unit=s"`
+		Fraction time.Duration  `config:"fraction,unit=s"`
+		Nanos    time.Duration  `config:"nanos"`
+		Pointer  *time.Duration `config:"pointer,unit=ms"`
+		Default  time.Duration  `config:"default,default=1h30m"`
+		Size     ByteSize       `config:"size"`
+		Decimal  ByteSize       `config:"decimal"`
+		Bytes    ByteSize       `config:"bytes,max=1KiB"`
+	}
+	ms := 250 * time.Millisecond
+	expected := &test{
+		Human:    90 * time.Minute,
+		Seconds:  30 * time.Second,
+		Fraction: 1500 * time.Millisecond,
+		Nanos:    100,
+		Pointer:  &ms,
+		Default:  90 * time.Minute,
+		Size:     10 * MiB,
+		Decimal:  2 * GB,
+		Bytes:    512,
+	}
+
+	tests := []struct {
+		name   string
+		source func() backend.Backend
+	}{
+		{
+			name: "json",
+			source: func() backend.Backend {
+				return file.New(file.WithPath(
+					suite.createFileForTest([]byte(`{"human":"1h30m","seconds":30,"fraction":1.5,"nanos":100,` +
+						`"pointer":250,"size":"10MiB","decimal":"2GB","bytes":512}`)).Name(),
+				))
+			},
+		},
+		{
+			name: "yaml",
+			source: func() backend.Backend {
+				return file.New(file.WithPath(
+					suite.createFileForTest([]byte(`
+human: 1h30m
+seconds: 30
+fraction: 1.5
+nanos: 100
+pointer: 250
+size: 10MiB
+decimal: 2GB
+bytes: 512
+`)).Name(),
+				), file.WithOption(backend.WithEncoder(yaml.New())))
+			},
+		},
+		{
+			name: "toml",
+			source: func() backend.Backend {
+				return file.New(file.WithPath(
+					suite.createFileForTest([]byte(`
+human = "1h30m"
+seconds = 30
+fraction = 1.5
+nanos = 100
+pointer = 250
+size = "10MiB"
+decimal = "2GB"
+bytes = 512
+`)).Name(),
+				), file.WithOption(backend.WithEncoder(toml.New())))
+			},
+		},
+		{
+			name: "env",
+			source: func() backend.Backend {
+				return env.New(env.WithDefaults(
+					suite.createFileForTest([]byte(`
+DURATION_HUMAN=1h30m
+DURATION_SECONDS=30
+DURATION_FRACTION=1.5
+DURATION_NANOS=100
+DURATION_POINTER=250
+DURATION_SIZE=10MiB
+DURATION_DECIMAL=2GB
+DURATION_BYTES=512
+`)).Name(),
+				), env.WithStripPrefix("DURATION_"))
+			},
+		},
+	}
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			loader, err := NewLoader(suite.ctx, tt.source())
+			suite.Require().Nil(err)
+			cfg := &test{}
+			c := &config{
+				structs: cfg,
+			}
+			suite.Nil(loader.Load(c))
+			suite.Nil(c.err)
+			suite.Equal(expected, cfg)
+		})
+	}
+
+	type lists struct {
+		List     []time.Duration            `config:"list,unit=s"`
+		Array    [2]time.Duration           `config:"array"`
+		Pointers []*time.Duration           `config:"pointers,unit=ms"`
+		Timeouts map[string][]time.Duration `config:"timeouts,unit=m"`
+	}
+	second, ms250 := time.Second, 250*time.Millisecond
+	expectedLists := &lists{
+		List:     []time.Duration{90 * time.Second, 2 * time.Second},
+		Array:    [2]time.Duration{time.Hour, 5},
+		Pointers: []*time.Duration{&second, &ms250},
+		Timeouts: map[string][]time.Duration{"read": {time.Minute, 2 * time.Minute}},
+	}
+	listTests := []struct {
+		name string
+		data string
+		enc  encoder.Encoder
+	}{
+		{
+			name: "json lists",
+			data: `{"list":["1m30s",2],"array":["1h",5],"pointers":["1s",250],"timeouts":{"read":[1,"2"]}}`,
+			enc:  json.New(),
+		},
+		{
+			name: "yaml lists",
+			data: `
+list: [1m30s, 2]
+array: [1h, 5]
+pointers: [1s, 250]
+timeouts:
+  read: [1, "2"]
+`,
+			enc: yaml.New(),
+		},
+		{
+			name: "toml lists",
+			data: `
+list = ["1m30s", "2"]
+array = ["1h", "5"]
+pointers = ["1s", "250"]
+[timeouts]
+read = ["1", "2"]
+`,
+			enc: toml.New(),
+		},
+	}
+	for _, tt := range listTests {
+		suite.Run(tt.name, func() {
+			loader, err := NewLoader(suite.ctx, file.New(file.WithPath(
+				suite.createFileForTest([]byte(tt.data)).Name(),
+			), file.WithOption(backend.WithEncoder(tt.enc))))
+			suite.Require().Nil(err)
+			cfg := &lists{}
+			c := &config{
+				structs: cfg,
+			}
+			suite.Nil(loader.Load(c))
+			suite.Nil(c.err)
+			suite.Equal(expectedLists, cfg)
+		})
+	}
+
+	size, err := ParseByteSize("1.5 KiB")
+	suite.Nil(err)
+	suite.Equal(ByteSize(1536), size)
+	suite.Equal("10MiB", (10 * MiB).String())
+	suite.Equal("2GB", (2 * GB).String())
+	suite.Equal("1001B", ByteSize(1001).String())
+	_, err = ParseByteSize("10XB")
+	suite.Error(err)
+	_, err = ParseByteSize("20000000PiB")
+	suite.Error(err)
+	_, err = ParseByteSize("20000000.5PiB")
+	suite.Error(err)
+
+	loader, err := NewLoader(suite.ctx,
+		file.New(file.WithPath(
+			suite.createFileForTest([]byte(`{"seconds":30000000000,"fraction":1e10,"size":"20000000PiB"}`)).Name(),
+		)),
+	)
+	suite.Require().Nil(err)
+	c := &config{
+		structs: &test{},
+	}
+	suite.Nil(loader.Load(c))
+	var decodeErrs []string
+	for _, err := range c.err.(interface{ Unwrap() []error }).Unwrap() {
+		var derr *DecodeError
+		if suite.ErrorAs(err, &derr) {
+			decodeErrs = append(decodeErrs, derr.Key)
+		}
+	}
+	suite.ElementsMatch([]string{"seconds", "fraction", "size"}, decodeErrs)
+}
+
+func (suite *ConfigTestSuite) TestDumpDuration() {
+	type test struct {
+		Seconds time.Duration   `config:"seconds,unit=s"`
+		Pointer *time.Duration  `config:"pointer,unit=ms"`
+		List    []time.Duration `config:"list,unit=m"`
+		Size    ByteSize        `config:"size"`
+	}
+	loader, err := NewLoader(suite.ctx,
+		file.New(file.WithPath(
+			suite.createFileForTest([]byte(`{"seconds":30,"pointer":250,"list":[1,90],"size":"10MiB"}`)).Name(),
+		)),
+	)
+	suite.Require().Nil(err)
+	cfg := &test{}
+	c := &config{
+		structs: cfg,
+	}
+	suite.Nil(loader.Load(c))
+	suite.Nil(c.err)
+
+	b, err := loader.Dump(c, json.New())
+	suite.Nil(err)
+	suite.JSONEq(`{"seconds":"30s","pointer":"250ms","list":["1m0s","1h30m0s"],"size":"10MiB"}`, string(b))
+
+	loader, err = NewLoader(suite.ctx,
+		file.New(file.WithPath(suite.createFileForTest(b).Name())),
+	)
+	suite.Require().Nil(err)
+	dumped := &test{}
+	c = &config{
+		structs: dumped,
+	}
+	suite.Nil(loader.Load(c))
+	suite.Nil(c.err)
+	suite.Equal(cfg, dumped)
+}
+
+type level int
+
+type upper string
+
+func (u *upper) UnmarshalText(text []byte) error {
+	*u = upper(strings.ToUpper(string(text)))
+	return nil
+}
+
+func (suite *ConfigTestSuite) TestDecoders() {
+	type test struct {
+		IP       net.IP           `config:"ip"`
+		Hosts    []net.IP         `config:"hosts"`
+		Network  *net.IPNet       `config:"network"`
+		URL      *url.URL         `config:"url"`
+		Value    url.URL          `config:"url"`
+		Pattern  *regexp.Regexp   `config:"pattern"`
+		Location *time.Location   `config:"location"`
+		Time     time.Time        `config:"time"`
+		Date     time.Time        `config:"date"`
+		Level    level            `config:"level"`
+		Levels   map[string]level `config:"levels"`
+		Upper    upper            `config:"upper"`
+	}
+	tests := []struct {
+		name    string
+		encoder encoder.Encoder
+		data    string
+	}{
+		{
+			name:    "json",
+			encoder: json.New(),
+			data: `{"ip":"10.0.0.1","hosts":["10.0.0.2","::1"],"network":"10.0.0.0/8",
+"url":"https://example.com/path?q=1","pattern":"^a+$","location":"Europe/Budapest",
+"time":"2020-01-02T03:04:05Z","date":"2020-01-02","level":"debug","levels":{"db":"error"},"upper":"abc"}`,
+		},
+		{
+			name:    "yaml",
+			encoder: yaml.New(),
+			data: `
+ip: 10.0.0.1
+hosts:
+  - 10.0.0.2
+  - "::1"
+network: 10.0.0.0/8
+url: https://example.com/path?q=1
+pattern: ^a+$
+location: Europe/Budapest
+time: "2020-01-02T03:04:05Z"
+date: "2020-01-02"
+level: debug
+levels:
+  db: error
+upper: abc
+`,
+		},
+		{
+			name:    "toml",
+			encoder: toml.New(),
+			data: `
+ip = "10.0.0.1"
+hosts = ["10.0.0.2", "::1"]
+network = "10.0.0.0/8"
+url = "https://example.com/path?q=1"
+pattern = "^a+$"
+location = "Europe/Budapest"
+time = "2020-01-02T03:04:05Z"
+date = "2020-01-02"
+level = "debug"
+upper = "abc"
+[levels]
+db = "error"
+`,
+		},
+	}
+	levels := map[string]level{"debug": 1, "error": 3}
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			loader, err := NewLoader(suite.ctx, file.New(
+				file.WithPath(suite.createFileForTest([]byte(tt.data)).Name()),
+				file.WithOption(backend.WithEncoder(tt.encoder)),
+			))
+			suite.Require().Nil(err)
+			loader.RegisterDecoder(reflect.TypeOf(level(0)), func(raw interface{}, enc encoder.Encoder) (interface{}, error) {
+				var name string
+				if err := enc.Decode(raw, &name); err != nil {
+					return nil, err
+				}
+				l, ok := levels[name]
+				if !ok {
+					return nil, fmt.Errorf("unknown level '%s'", name)
+				}
+				return l, nil
+			})
+			cfg := &test{}
+			c := &config{
+				structs: cfg,
+			}
+			suite.Nil(loader.Load(c))
+			suite.Require().Nil(c.err)
+			suite.Equal(net.ParseIP("10.0.0.1"), cfg.IP)
+			suite.Equal([]net.IP{net.ParseIP("10.0.0.2"), net.ParseIP("::1")}, cfg.Hosts)
+			suite.Equal("10.0.0.0/8", cfg.Network.String())
+			suite.Equal("https://example.com/path?q=1", cfg.URL.String())
+			suite.Equal("example.com", cfg.Value.Host)
+			suite.True(cfg.Pattern.MatchString("aaa"))
+			suite.Equal("Europe/Budapest", cfg.Location.String())
+			suite.Equal(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), cfg.Time)
+			suite.Equal(time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), cfg.Date)
+			suite.Equal(level(1), cfg.Level)
+			suite.Equal(map[string]level{"db": 3}, cfg.Levels)
+			suite.Equal(upper("ABC"), cfg.Upper)
+
+			b, err := loader.Dump(c, tt.encoder)
+			suite.Require().Nil(err)
+			dumped := &test{}
+			c = &config{
+				structs: dumped,
+			}
+			loader, err = NewLoader(suite.ctx, file.New(
+				file.WithPath(suite.createFileForTest(b).Name()),
+				file.WithOption(backend.WithEncoder(tt.encoder)),
+			))
+			suite.Require().Nil(err)
+			// level has no text form, it is dumped as a number
+			loader.RegisterDecoder(reflect.TypeOf(level(0)), func(raw interface{}, enc encoder.Encoder) (interface{}, error) {
+				var n int
+				err := enc.Decode(raw, &n)
+				return level(n), err
+			})
+			suite.Nil(loader.Load(c))
+			suite.Require().Nil(c.err, string(b))
+			suite.Equal(cfg.IP, dumped.IP)
+			suite.Equal(cfg.Hosts, dumped.Hosts)
+			suite.Equal(cfg.Network, dumped.Network)
+			suite.Equal(cfg.URL, dumped.URL)
+			suite.Equal(cfg.Pattern.String(), dumped.Pattern.String())
+			suite.Equal(cfg.Location.String(), dumped.Location.String())
+			suite.True(cfg.Time.Equal(dumped.Time))
+			suite.Equal(cfg.Level, dumped.Level)
+		})
+	}
+
+	loader, err := NewLoader(suite.ctx, file.New(file.WithPath(
+		suite.createFileForTest([]byte(`{"ip":"10.0.0","pattern":"("}`)).Name(),
+	)))
+	suite.Require().Nil(err)
+	c := &config{
+		structs: &test{},
+	}
+	suite.Nil(loader.Load(c))
+	suite.Error(c.err)
+	suite.Contains(c.err.Error(), "invalid IP address '10.0.0'")
+	suite.Contains(c.err.Error(), "missing closing )")
+}
+
 func (suite *ConfigTestSuite) createFileForTest(data []byte) *os.File {
 	path := filepath.Join(os.TempDir(), fmt.Sprintf("file.%d", time.Now().UnixNano()))
 	fh, err := os.Create(path)
@@ -670,6 +2585,16 @@ func (suite *ConfigTestSuite) createFileForTest(data []byte) *os.File {
 	return fh
 }
 
+func (suite *ConfigTestSuite) updateFileForTest(f *os.File, data []byte) {
+	suite.Nil(f.Truncate(0))
+	_, err := f.WriteAt(data, 0)
+	suite.Nil(err)
+	suite.Nil(f.Sync())
+	// the file watcher compares the modification time and the size
+	now := time.Now()
+	suite.Nil(os.Chtimes(f.Name(), now, now))
+}
+
 type config struct {
 	sync.Mutex
 	structs interface{}
@@ -687,6 +2612,32 @@ func (c *config) SetSnapshot(i interface{}, err error) {
 	c.err = err
 }
 
+type freshConfig struct {
+	sync.Mutex
+	newSnapshot func() interface{}
+	structs     interface{}
+	err         error
+	setCalls    int
+}
+
+func (c *freshConfig) NewSnapshot() interface{} {
+	return c.newSnapshot()
+}
+
+func (c *freshConfig) SetSnapshot(i interface{}, err error) {
+	c.Lock()
+	defer c.Unlock()
+	c.structs = i
+	c.err = err
+	c.setCalls++
+}
+
+func (c *freshConfig) calls() int {
+	c.Lock()
+	defer c.Unlock()
+	return c.setCalls
+}
+
 /*
 
 Run benchmarking with: go test -bench '.'
@@ -0,0 +1,244 @@
+package config
+
+import (
+	"encoding"
+	stdjson "encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ak-Army/config/encoder"
+)
+
+var units = map[string]time.Duration{
+	"":   time.Nanosecond,
+	"ns": time.Nanosecond,
+	"us": time.Microsecond,
+	"µs": time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+}
+
+// DecodeFunc decodes the raw value of a key, as it was returned by the
+// encoder of the backend, into a value of the type it was registered for.
+type DecodeFunc func(raw interface{}, enc encoder.Encoder) (interface{}, error)
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// timeLayouts are the layouts tried in order to decode a time.Time.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+var defaultDecoders = map[reflect.Type]DecodeFunc{
+	reflect.TypeOf(net.IP{}): func(raw interface{}, enc encoder.Encoder) (interface{}, error) {
+		s, err := decodeString(enc, raw)
+		if err != nil {
+			return nil, err
+		}
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address '%s'", s)
+		}
+		return ip, nil
+	},
+	reflect.TypeOf(&net.IPNet{}): func(raw interface{}, enc encoder.Encoder) (interface{}, error) {
+		s, err := decodeString(enc, raw)
+		if err != nil {
+			return nil, err
+		}
+		_, n, err := net.ParseCIDR(s)
+		return n, err
+	},
+	reflect.TypeOf(&url.URL{}): func(raw interface{}, enc encoder.Encoder) (interface{}, error) {
+		s, err := decodeString(enc, raw)
+		if err != nil {
+			return nil, err
+		}
+		return url.Parse(s)
+	},
+	reflect.TypeOf(&regexp.Regexp{}): func(raw interface{}, enc encoder.Encoder) (interface{}, error) {
+		s, err := decodeString(enc, raw)
+		if err != nil {
+			return nil, err
+		}
+		return regexp.Compile(s)
+	},
+	reflect.TypeOf(&time.Location{}): func(raw interface{}, enc encoder.Encoder) (interface{}, error) {
+		s, err := decodeString(enc, raw)
+		if err != nil {
+			return nil, err
+		}
+		return time.LoadLocation(s)
+	},
+	reflect.TypeOf(time.Time{}): func(raw interface{}, enc encoder.Encoder) (interface{}, error) {
+		s, err := decodeString(enc, raw)
+		if err != nil {
+			return nil, err
+		}
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid time '%s'", s)
+	},
+}
+
+// RegisterDecoder registers the function used to decode the values of the
+// given type, instead of the encoder of the backend. It takes precedence
+// over the built-in decoders, it should be called before the first Load.
+func (l *Loader) RegisterDecoder(typ reflect.Type, fn DecodeFunc) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.decoders == nil {
+		l.decoders = make(map[reflect.Type]DecodeFunc)
+	}
+	l.decoders[typ] = fn
+}
+
+// decoder returns the decoder registered for the type, or for the pointer
+// to the type, or the one used for the TextUnmarshaler implementations.
+func (l *Loader) decoder(typ reflect.Type) (DecodeFunc, bool) {
+	for _, t := range []reflect.Type{typ, reflect.PtrTo(typ)} {
+		if fn, ok := l.decoders[t]; ok {
+			return fn, true
+		}
+		if fn, ok := defaultDecoders[t]; ok {
+			return fn, true
+		}
+	}
+	if typ.Implements(textUnmarshalerType) || reflect.PtrTo(typ).Implements(textUnmarshalerType) {
+		return func(raw interface{}, enc encoder.Encoder) (interface{}, error) {
+			s, err := decodeString(enc, raw)
+			if err != nil {
+				return nil, err
+			}
+			v := reflect.New(typ).Elem()
+			target := v.Addr()
+			if typ.Kind() == reflect.Ptr {
+				v.Set(reflect.New(typ.Elem()))
+				target = v
+			}
+			if err := target.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+				return nil, err
+			}
+			return v.Interface(), nil
+		}, true
+	}
+	return nil, false
+}
+
+// decode decodes the raw value of a leaf field into to, a pointer to a value
+// of the field's type.
+func (l *Loader) decode(f *field, enc encoder.Encoder, raw interface{}, to reflect.Value) error {
+	typ := to.Elem().Type()
+	if fn, ok := l.decoder(typ); ok {
+		v, err := fn(raw, enc)
+		if err != nil {
+			return err
+		}
+		return assign(to.Elem(), reflect.ValueOf(v))
+	}
+	switch {
+	case to.Elem().Type() == durationType:
+		d, err := decodeDuration(enc, raw, f.unit)
+		if err != nil {
+			return err
+		}
+		to.Elem().SetInt(int64(d))
+		return nil
+	case to.Elem().Kind() == reflect.Ptr && to.Elem().Type().Elem() == durationType:
+		d, err := decodeDuration(enc, raw, f.unit)
+		if err != nil {
+			return err
+		}
+		to.Elem().Set(reflect.ValueOf(&d))
+		return nil
+	}
+	return enc.Decode(raw, to.Interface())
+}
+
+// assign sets to to the decoded value v, dereferencing or taking the address
+// of v when the decoder returned a pointer for a value or the other way
+// round.
+func assign(to, v reflect.Value) error {
+	switch {
+	case !v.IsValid():
+		to.Set(reflect.Zero(to.Type()))
+	case v.Type().AssignableTo(to.Type()):
+		to.Set(v)
+	case v.Kind() == reflect.Ptr && v.Type().Elem().AssignableTo(to.Type()):
+		if v.IsNil() {
+			to.Set(reflect.Zero(to.Type()))
+		} else {
+			to.Set(v.Elem())
+		}
+	case to.Kind() == reflect.Ptr && v.Type().AssignableTo(to.Type().Elem()):
+		p := reflect.New(to.Type().Elem())
+		p.Elem().Set(v)
+		to.Set(p)
+	default:
+		return fmt.Errorf("decoder returned %s for %s", v.Type(), to.Type())
+	}
+	return nil
+}
+
+// decodeString returns the raw value as a string. Other scalars are returned
+// in their JSON form, so the yaml and toml encoders can hand back numbers for
+// values the hooks parse from text.
+func decodeString(enc encoder.Encoder, raw interface{}) (string, error) {
+	var msg stdjson.RawMessage
+	if err := enc.Decode(raw, &msg); err != nil {
+		return "", err
+	}
+	s := strings.TrimSpace(string(msg))
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = strings.TrimSpace(unquoted)
+	}
+	return s, nil
+}
+
+// decodeDuration accepts durations like "1h30m", and plain numbers that are
+// multiplied by the unit given in the unit= option of the tag, nanoseconds
+// by default. Numbers in strings are accepted too, the env backend provides
+// every value as a string.
+func decodeDuration(enc encoder.Encoder, raw interface{}, unit string) (time.Duration, error) {
+	u, ok := units[unit]
+	if !ok {
+		return 0, fmt.Errorf("unknown duration unit '%s'", unit)
+	}
+	s, err := decodeString(enc, raw)
+	if err != nil {
+		return 0, err
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if n > math.MaxInt64/int64(u) || n < math.MinInt64/int64(u) {
+			return 0, fmt.Errorf("duration '%s' overflows with unit '%s'", s, unit)
+		}
+		return time.Duration(n) * u, nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		// written so NaN fails the check too
+		d := f * float64(u)
+		if !(d >= math.MinInt64 && d < math.MaxInt64) {
+			return 0, fmt.Errorf("duration '%s' overflows with unit '%s'", s, unit)
+		}
+		return time.Duration(d), nil
+	}
+	return time.ParseDuration(s)
+}
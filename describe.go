@@ -0,0 +1,94 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+)
+
+// KeyInfo describes a key of a config struct, as read from its config tag.
+// In Key and Field, "[]" stands for the items of a list and "*" for the
+// entries of a map.
+type KeyInfo struct {
+	Key         string
+	Field       string
+	Type        string
+	Default     string
+	HasDefault  bool
+	Required    bool
+	Secret      bool
+	Description string
+	// Unit is the unit of the numbers given for a time.Duration.
+	Unit string
+	// Backend is the backend given in the backend= option of the field or
+	// of its closest parent having one, the key is read from every backend
+	// when it is empty.
+	Backend string
+}
+
+// Describe returns every key the snapshot is loaded from, in the order of
+// the struct fields. Structs, lists and maps are described by their own key
+// and the keys of their fields or elements, which are read from the backend
+// of their parent when it has one.
+func (l *Loader) Describe(snapshot interface{}) ([]KeyInfo, error) {
+	ref := reflect.ValueOf(snapshot)
+	if !ref.IsValid() || ref.Kind() != reflect.Ptr || ref.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("provided target must be a pointer to struct")
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.describe(l.parseStruct(ref.Elem()), "", "", "", nil), nil
+}
+
+func (l *Loader) describe(fields []*field, key, name, source string, keys []KeyInfo) []KeyInfo {
+	for _, f := range fields {
+		fieldSource := source
+		if f.source != "" {
+			fieldSource = f.source
+		}
+		if f.inline {
+			keys = l.describe(f.subFields, key, name, fieldSource, keys)
+			continue
+		}
+		fieldKey, fieldName := joinKey(key, f.key), joinKey(name, f.name)
+		keys = append(keys, KeyInfo{
+			Key:         fieldKey,
+			Field:       fieldName,
+			Type:        f.value.Type().String(),
+			Default:     f.def,
+			HasDefault:  f.hasDef,
+			Required:    f.required,
+			Secret:      f.secret,
+			Description: f.desc,
+			Unit:        f.unit,
+			Backend:     fieldSource,
+		})
+		switch {
+		case len(f.subFields) != 0:
+			keys = l.describe(f.subFields, fieldKey, fieldName, fieldSource, keys)
+		case f.isList:
+			keys = l.describeElem(f.value.Type().Elem(), fieldKey+"[]", fieldName+"[]", fieldSource, keys)
+		case f.isMap:
+			keys = l.describeElem(f.value.Type().Elem(), fieldKey+".*", fieldName+"[*]", fieldSource, keys)
+		}
+	}
+	return keys
+}
+
+// describeElem describes the keys of the elements of a list or a map.
+func (l *Loader) describeElem(typ reflect.Type, key, name, source string, keys []KeyInfo) []KeyInfo {
+	if !l.composite(typ) {
+		return keys
+	}
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.Struct:
+		return l.describe(l.parseStruct(reflect.New(typ).Elem()), key, name, source, keys)
+	case reflect.Slice, reflect.Array:
+		return l.describeElem(typ.Elem(), key+"[]", name+"[]", source, keys)
+	case reflect.Map:
+		return l.describeElem(typ.Elem(), key+".*", name+"[*]", source, keys)
+	}
+	return keys
+}
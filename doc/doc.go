@@ -0,0 +1,145 @@
+// Package doc renders the reference documentation of the keys of config
+// structs, as Markdown or plain text tables.
+package doc
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Ak-Army/config"
+)
+
+var header = []string{"Key", "Type", "Default", "Required", "Description", "Backends", "Env"}
+
+// Doc renders the reference of the keys of a config struct.
+type Doc struct {
+	keys       []config.KeyInfo
+	envPrefix  string
+	envBackend string
+}
+
+type Option func(d *Doc)
+
+// WithEnvPrefix sets the prefix the env backend strips from the variables,
+// see env.WithStripPrefix.
+func WithEnvPrefix(prefix string) Option {
+	return func(d *Doc) {
+		d.envPrefix = prefix
+	}
+}
+
+// WithEnvBackend sets the name of the env backend, see backend.WithName. It
+// is "env" by default. The keys read from another backend have no env
+// variable.
+func WithEnvBackend(name string) Option {
+	return func(d *Doc) {
+		d.envBackend = name
+	}
+}
+
+// New returns the documentation of the keys, as returned by
+// config.Loader.Describe.
+func New(keys []config.KeyInfo, opts ...Option) *Doc {
+	d := &Doc{
+		keys:       keys,
+		envBackend: "env",
+	}
+	for _, o := range opts {
+		o(d)
+	}
+	return d
+}
+
+// EnvName returns the variable the env backend maps to the key, or an empty
+// string when the key is nested, the env backend only provides top level
+// keys.
+func (d *Doc) EnvName(key string) string {
+	if key == "" || strings.ContainsAny(key, ".[*") {
+		return ""
+	}
+	return d.envPrefix + strings.ToUpper(strings.Replace(key, "-", "_", -1))
+}
+
+// Markdown writes the keys as a Markdown table.
+func (d *Doc) Markdown(w io.Writer) error {
+	rows := d.rows(func(s string) string {
+		if s == "" {
+			return ""
+		}
+		return "`" + s + "`"
+	})
+	line := func(cells []string) string {
+		for i, c := range cells {
+			c = strings.Replace(c, "\n", " ", -1)
+			cells[i] = strings.Replace(c, "|", `\|`, -1)
+		}
+		return "| " + strings.Join(cells, " | ") + " |\n"
+	}
+	separator := make([]string, len(header))
+	for i := range separator {
+		separator[i] = "---"
+	}
+	out := line(append([]string(nil), header...)) + line(separator)
+	for _, row := range rows {
+		out += line(row)
+	}
+	_, err := io.WriteString(w, out)
+	return err
+}
+
+// Text writes the keys as a plain text table with aligned columns.
+func (d *Doc) Text(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range d.rows(func(s string) string { return s }) {
+		for i, c := range row {
+			if c == "" {
+				row[i] = "-"
+			}
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// rows returns the cells of every key, code formats the keys, types, default
+// values and env variables.
+func (d *Doc) rows(code func(string) string) [][]string {
+	rows := make([][]string, len(d.keys))
+	for i, k := range d.keys {
+		def := ""
+		if k.HasDefault {
+			def = code(k.Default)
+			if k.Secret {
+				def = config.SecretMask
+			}
+		}
+		required := ""
+		if k.Required {
+			required = "yes"
+		}
+		typ := code(k.Type)
+		if k.Unit != "" {
+			typ += " (" + k.Unit + ")"
+		}
+		backends, env := "any", d.EnvName(k.Key)
+		if k.Backend != "" {
+			backends = k.Backend
+			if k.Backend != d.envBackend {
+				env = ""
+			}
+		}
+		rows[i] = []string{
+			code(k.Key),
+			typ,
+			def,
+			required,
+			k.Description,
+			backends,
+			code(env),
+		}
+	}
+	return rows
+}
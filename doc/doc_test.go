@@ -0,0 +1,81 @@
+package doc
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/Ak-Army/config"
+)
+
+type DocTestSuite struct {
+	suite.Suite
+	keys []config.KeyInfo
+}
+
+func TestDoc(t *testing.T) {
+	suite.Run(t, new(DocTestSuite))
+}
+
+func (suite *DocTestSuite) SetupTest() {
+	suite.keys = []config.KeyInfo{
+		{Key: "name", Field: "Name", Type: "string", Required: true, Description: "The name | alias"},
+		{Key: "max-conns", Field: "MaxConns", Type: "int", Default: "10", HasDefault: true},
+		{Key: "timeout", Field: "Timeout", Type: "time.Duration", Default: "30", HasDefault: true, Unit: "s"},
+		{Key: "password", Field: "Password", Type: "string", Default: "hunter2", HasDefault: true, Secret: true,
+			Backend: "env"},
+		{Key: "db.host", Field: "DB.Host", Type: "string", Default: "a|b", HasDefault: true},
+		{Key: "token", Field: "Token", Type: "string", Backend: "vault"},
+		{Key: "servers[].port", Field: "Servers[].Port", Type: "int"},
+	}
+}
+
+func (suite *DocTestSuite) TestMarkdown() {
+	var b bytes.Buffer
+	suite.Nil(New(suite.keys, WithEnvPrefix("APP_")).Markdown(&b))
+	suite.Equal("| Key | Type | Default | Required | Description | Backends | Env |\n"+
+		"| --- | --- | --- | --- | --- | --- | --- |\n"+
+		"| `name` | `string` |  | yes | The name \\| alias | any | `APP_NAME` |\n"+
+		"| `max-conns` | `int` | `10` |  |  | any | `APP_MAX_CONNS` |\n"+
+		"| `timeout` | `time.Duration` (s) | `30` |  |  | any | `APP_TIMEOUT` |\n"+
+		"| `password` | `string` | ****** |  |  | env | `APP_PASSWORD` |\n"+
+		"| `db.host` | `string` | `a\\|b` |  |  | any |  |\n"+
+		"| `token` | `string` |  |  |  | vault |  |\n"+
+		"| `servers[].port` | `int` |  |  |  | any |  |\n", b.String())
+	suite.NotContains(b.String(), "hunter2")
+
+	b.Reset()
+	suite.Nil(New(suite.keys, WithEnvBackend("vars")).Markdown(&b))
+	suite.Contains(b.String(), "| `password` | `string` | ****** |  |  | env |  |\n")
+	suite.Contains(b.String(), "| `name` | `string` |  | yes | The name \\| alias | any | `NAME` |\n")
+}
+
+func (suite *DocTestSuite) TestText() {
+	var b bytes.Buffer
+	suite.Nil(New(suite.keys).Text(&b))
+	suite.Equal(`Key             Type               Default  Required  Description       Backends  Env
+name            string             -        yes       The name | alias  any       NAME
+max-conns       int                10       -         -                 any       MAX_CONNS
+timeout         time.Duration (s)  30       -         -                 any       TIMEOUT
+password        string             ******   -         -                 env       PASSWORD
+db.host         string             a|b      -         -                 any       -
+token           string             -        -         -                 vault     -
+servers[].port  int                -        -         -                 any       -
+`, b.String())
+	suite.NotContains(b.String(), "hunter2")
+}
+
+func (suite *DocTestSuite) TestEnvName() {
+	d := New(nil)
+	suite.Equal("NAME", d.EnvName("name"))
+	suite.Equal("MAX_CONNS", d.EnvName("max-conns"))
+	suite.Equal("", d.EnvName("db.host"))
+	suite.Equal("", d.EnvName("servers[].port"))
+	suite.Equal("", d.EnvName("entries.*"))
+	suite.Equal("", d.EnvName(""))
+
+	d = New(nil, WithEnvPrefix("APP_"))
+	suite.Equal("APP_MAX_CONNS", d.EnvName("max-conns"))
+	suite.Equal("", d.EnvName("db.host"))
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/Ak-Army/config/encoder"
+)
+
+// SecretMask replaces the values of the fields tagged as secret in Dump.
+const SecretMask = "******"
+
+// Dump renders the last snapshot applied to the config with the encoder,
+// using the keys of the config tags. The values of the fields tagged with
+// the secret option are replaced by SecretMask.
+func (l *Loader) Dump(c Config, enc encoder.Encoder) ([]byte, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, w := range l.backendWatcher {
+		if w.config != c {
+			continue
+		}
+		if w.snapshot == nil {
+			return nil, errors.New("no snapshot applied")
+		}
+		fields := l.parseStruct(reflect.ValueOf(w.snapshot).Elem())
+		return enc.Encode(l.dump(fields, make(map[string]interface{})))
+	}
+	return nil, ErrNotLoaded
+}
+
+func (l *Loader) dump(fields []*field, m map[string]interface{}) map[string]interface{} {
+	for _, f := range fields {
+		if f.inline {
+			if f.value.Kind() != reflect.Ptr || !f.value.IsNil() {
+				l.dump(f.subFields, m)
+			}
+			continue
+		}
+		v, ok := l.dumpValue(f)
+		if !ok {
+			continue
+		}
+		key := f.key
+		if key == "" {
+			key = f.name
+		}
+		if f.entry {
+			m[key] = v
+			continue
+		}
+		setKey(m, key, v)
+	}
+	return m
+}
+
+// dumpValue returns the value of the field to dump, or false when it should
+// be left out.
+func (l *Loader) dumpValue(f *field) (interface{}, bool) {
+	switch {
+	case f.isList:
+		if f.value.Kind() == reflect.Slice && f.value.IsNil() {
+			return nil, false
+		}
+		list := make([]interface{}, f.value.Len())
+		for i := range list {
+			list[i], _ = l.dumpValue(l.newItem(f, f.value, i))
+		}
+		return list, true
+	case f.isMap:
+		if f.value.IsNil() {
+			return nil, false
+		}
+		entries := make(map[string]interface{})
+		iter := f.value.MapRange()
+		for iter.Next() {
+			l.dump([]*field{l.newEntry(f, f.value, iter.Key().String())}, entries)
+		}
+		return entries, true
+	case len(f.subFields) != 0:
+		if f.value.Kind() == reflect.Ptr && f.value.IsNil() {
+			return nil, false
+		}
+		return l.dump(f.subFields, make(map[string]interface{})), true
+	case f.secret && !f.value.IsZero():
+		return SecretMask, true
+	}
+	if _, ok := l.decoder(f.value.Type()); ok {
+		if text, ok := marshalText(f.value); ok {
+			return text, true
+		}
+	}
+	v := printable(f.value)
+	if d, ok := v.(time.Duration); ok {
+		// numbers would be read back with the unit of the field
+		return d.String(), true
+	}
+	return v, v != nil
+}
+
+// marshalText returns the value as text, the way the decoders read it, when
+// the value or a pointer to it implements encoding.TextMarshaler or
+// fmt.Stringer. Printing *url.URL or *regexp.Regexp by their fields could
+// not be loaded again.
+func marshalText(v reflect.Value) (string, bool) {
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		return "", false
+	}
+	if v.Kind() != reflect.Ptr && v.CanAddr() {
+		v = v.Addr()
+	}
+	switch m := v.Interface().(type) {
+	case encoding.TextMarshaler:
+		text, err := m.MarshalText()
+		return string(text), err == nil
+	case fmt.Stringer:
+		return m.String(), true
+	}
+	return "", false
+}
+
+// setKey stores the value under the key, a dotted key is stored in nested
+// maps, the way the backends provide it.
+func setKey(m map[string]interface{}, key string, v interface{}) {
+	parts := strings.Split(key, ".")
+	target := m
+	for _, part := range parts[:len(parts)-1] {
+		sub, ok := target[part].(map[string]interface{})
+		if !ok {
+			if _, found := target[part]; found {
+				m[key] = v
+				return
+			}
+			sub = make(map[string]interface{})
+			target[part] = sub
+		}
+		target = sub
+	}
+	target[parts[len(parts)-1]] = v
+}
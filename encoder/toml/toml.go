@@ -62,8 +62,11 @@ func (t tomlEncoder) DecodeData(data interface{}) (encoder.Data, error) {
 		return encoderData, nil
 	}
 	if d, ok := data.(innerToml); ok {
+		data = json.RawMessage(d.InnerToml)
+	}
+	if d, ok := data.(json.RawMessage); ok {
 		ret := make(map[string]json.RawMessage)
-		err := json.Unmarshal(d.InnerToml, &ret)
+		err := json.Unmarshal(d, &ret)
 		if err != nil {
 			return nil, err
 		}
@@ -83,6 +86,7 @@ func (t tomlEncoder) DecodeDataList(data interface{}) ([]encoder.Data, error) {
 		}
 		encoderData := make([]encoder.Data, len(rets))
 		for i, ret := range rets {
+			encoderData[i] = encoder.Data{}
 			for k, v := range ret {
 				encoderData[i][k] = v
 			}
@@ -90,13 +94,17 @@ func (t tomlEncoder) DecodeDataList(data interface{}) ([]encoder.Data, error) {
 		return encoderData, nil
 	}
 	if d, ok := data.(innerToml); ok {
-		var rets []map[string]innerToml
-		err := json.Unmarshal(d.InnerToml, &rets)
+		data = json.RawMessage(d.InnerToml)
+	}
+	if d, ok := data.(json.RawMessage); ok {
+		var rets []map[string]json.RawMessage
+		err := json.Unmarshal(d, &rets)
 		if err != nil {
 			return nil, err
 		}
 		encoderData := make([]encoder.Data, len(rets))
 		for i, ret := range rets {
+			encoderData[i] = encoder.Data{}
 			for k, v := range ret {
 				encoderData[i][k] = v
 			}
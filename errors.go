@@ -0,0 +1,158 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrClosed is returned by the methods of a closed Loader.
+	ErrClosed = errors.New("loader is closed")
+	// ErrNotLoaded is returned for a Config that was never passed to Load,
+	// or that was unloaded since.
+	ErrNotLoaded = errors.New("config is not loaded")
+)
+
+// WatchError is passed to the OnError handlers when the watcher of a
+// backend fails to read or decode the changed content.
+type WatchError struct {
+	Backend string
+	Err     error
+}
+
+func (e *WatchError) Error() string {
+	return fmt.Sprintf("watching backend '%s' failed: %s", e.Backend, e.Err)
+}
+
+func (e *WatchError) Unwrap() error {
+	return e.Err
+}
+
+// MissingKeyError describes a required key that no backend provides. Key is
+// the full dotted path of the key and Field the path of the struct field.
+type MissingKeyError struct {
+	Key   string
+	Field string
+}
+
+func (e *MissingKeyError) Error() string {
+	return fmt.Sprintf("required key '%s' for field '%s' not found", e.Key, e.Field)
+}
+
+// MissingKeysError holds every required key missing from a snapshot.
+type MissingKeysError []*MissingKeyError
+
+func (e MissingKeysError) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("missing required keys: %s", strings.Join(msgs, "\n"))
+}
+
+func (e MissingKeysError) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// DecodeError describes a value a backend provides that can not be decoded
+// into its field. Key is the full dotted path of the key, Field the path of
+// the struct field and Source the source of the backend's content.
+type DecodeError struct {
+	Key     string
+	Field   string
+	Backend string
+	Source  string
+	Err     error
+}
+
+func (e *DecodeError) Error() string {
+	from := fmt.Sprintf("backend '%s'", e.Backend)
+	if e.Source != "" && e.Source != e.Backend {
+		from = fmt.Sprintf("backend '%s' (%s)", e.Backend, e.Source)
+	}
+	return fmt.Sprintf("decoding key '%s' for field '%s' from %s failed: %s", e.Key, e.Field, from, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// UnknownBackendError is returned for a backend the Loader does not have,
+// named in the backend= option of a field, or passed to RemoveSource. Key
+// and Field are empty in the latter case.
+type UnknownBackendError struct {
+	Backend string
+	Key     string
+	Field   string
+}
+
+func (e *UnknownBackendError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("unknown backend '%s'", e.Backend)
+	}
+	return fmt.Sprintf("unknown backend '%s' for key '%s' (field '%s')", e.Backend, e.Key, e.Field)
+}
+
+// UnknownKeyError describes a key of a strict backend that none of the
+// loaded configs use.
+type UnknownKeyError struct {
+	Backend string
+	Key     string
+}
+
+func (e *UnknownKeyError) Error() string {
+	return fmt.Sprintf("unknown key '%s' in backend '%s'", e.Key, e.Backend)
+}
+
+// SourceReadError is returned when a backend fails to read its content.
+type SourceReadError struct {
+	Backend string
+	Err     error
+}
+
+func (e *SourceReadError) Error() string {
+	return fmt.Sprintf("reading backend '%s' failed: %s", e.Backend, e.Err)
+}
+
+func (e *SourceReadError) Unwrap() error {
+	return e.Err
+}
+
+// withPath prefixes the key and field paths of the errors of the children of
+// a field with the paths of the field.
+func withPath(f *field, errs []error) []error {
+	if f.inline {
+		return errs
+	}
+	for _, err := range errs {
+		switch e := err.(type) {
+		case *DecodeError:
+			e.Key, e.Field = joinKey(f.key, e.Key), joinKey(f.name, e.Field)
+		case *UnknownBackendError:
+			e.Key, e.Field = joinKey(f.key, e.Key), joinKey(f.name, e.Field)
+		}
+	}
+	return errs
+}
+
+// decodeError returns the error of decoding the field from the layer.
+func decodeError(f *field, ly layer, err error) error {
+	e := &DecodeError{
+		Key:     f.key,
+		Field:   f.name,
+		Backend: ly.name,
+		Err:     err,
+	}
+	if ly.content != nil {
+		e.Source = ly.content.Source
+	}
+	return e
+}
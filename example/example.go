@@ -4,84 +4,41 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"sync"
+	"os"
 	"time"
 
 	"github.com/Ak-Army/config"
 	"github.com/Ak-Army/config/backend/env"
 	"github.com/Ak-Army/config/backend/file"
+	"github.com/Ak-Army/config/doc"
+	"github.com/Ak-Army/config/encoder/json"
 )
 
 type Config struct {
-	RecallCheckInterval  time.Duration `config:"recall-check-interval"`
-	QueueThreshold       int           `config:"queue-threshold"`
-	CallCheckInterval    time.Duration `config:"call-check-interval"`
-	CallConsumer         time.Duration `config:"call-consumer"`
-	CallRemoveInterval   time.Duration `config:"call-remove-interval"`
-	FailedCallThreshold  int64         `config:"failed-call-threshold"`
-	StatPublishInterval  time.Duration `config:"stat-publish-interval"`
-	AutoRemoveInterval   time.Duration `config:"auto-remove-interval"`
-	DialerProjectCheck   time.Duration `config:"dialer-project-check"`
-	StatCrawlingInterval time.Duration `config:"stat-crawling-interval"`
+	RecallCheckInterval  time.Duration `config:",default=30,unit=s"`
+	QueueThreshold       int           `config:",default=400"`
+	CallCheckInterval    time.Duration `config:",default=10,unit=s"`
+	CallConsumer         time.Duration `config:",default=10,unit=ms"`
+	CallRemoveInterval   time.Duration `config:",default=1,unit=s"`
+	FailedCallThreshold  int64         `config:",default=10"`
+	StatPublishInterval  time.Duration `config:",default=1,unit=s"`
+	AutoRemoveInterval   time.Duration `config:",default=30,unit=s"`
+	DialerProjectCheck   time.Duration `config:",default=60,unit=s"`
+	StatCrawlingInterval time.Duration `config:",unit=s"`
 	Amd2Config           *Amd2Config   `config:"amd2"`
-}
-
-type configStore struct {
-	sync.RWMutex
-	config Config
-	err    error
+	SentryDSN            string        `config:"sentry_dsn_prod,secret"`
 }
 
 type Amd2Config struct {
-	Active              bool           `config:"active"`
-	PhoneNumberPrefixes []string       `config:"phone-number-prefixes"`
-	AppParams           *Amd2AppParams `config:"app-params"`
+	Active              bool
+	PhoneNumberPrefixes []string
+	AppParams           *Amd2AppParams
 }
 
 type Amd2AppParams struct {
-	Record         int    `config:"record"`
-	AnalyzedLength int64  `config:"analyzed_length"`
-	Filepath       string `config:"filepath"`
-}
-
-func (c *configStore) NewSnapshot() interface{} {
-	return &Config{
-		RecallCheckInterval: 30,
-		QueueThreshold:      400,
-		CallCheckInterval:   10,
-		CallConsumer:        10,
-		FailedCallThreshold: 10,
-		CallRemoveInterval:  1,
-		StatPublishInterval: 1,
-		AutoRemoveInterval:  30,
-		DialerProjectCheck:  60,
-		Amd2Config: &Amd2Config{
-			AppParams: &Amd2AppParams{},
-		},
-	}
-}
-
-func (c *configStore) SetSnapshot(confInterface interface{}, err error) {
-	c.Lock()
-	defer c.Unlock()
-	conf := confInterface.(*Config)
-
-	conf.RecallCheckInterval *= time.Second
-	conf.CallCheckInterval *= time.Second
-	conf.CallConsumer *= time.Millisecond
-	conf.CallRemoveInterval *= time.Second
-	conf.StatPublishInterval *= time.Second
-	conf.AutoRemoveInterval *= time.Second
-	conf.DialerProjectCheck *= time.Second
-	conf.StatCrawlingInterval *= time.Second
-	c.config = *conf
-	c.err = err
-}
-
-func (c *configStore) Config() (Config, error) {
-	c.RLock()
-	defer c.RUnlock()
-	return c.config, c.err
+	Record         int
+	AnalyzedLength int64 `config:"analyzed_length"`
+	Filepath       string
 }
 
 func main() {
@@ -92,11 +49,24 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
-	c := &configStore{}
-	err = loader.Load(c)
+	loader.SetOptions(config.WithNaming(config.NamingKebab))
+	store, err := config.Bind[Config](loader, nil)
 	if err != nil {
 		log.Fatal(err)
 	}
-	conf, err := c.Config()
-	fmt.Printf("%+v, err: %s\n", conf, err)
+	store.Subscribe(func(old, new *Config) {
+		fmt.Printf("queue threshold: %d -> %d\n", old.QueueThreshold, new.QueueThreshold)
+	})
+	b, err := loader.Dump(store.Config(), json.New())
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%s\n", b)
+	keys, err := loader.Describe(&Config{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := doc.New(keys).Markdown(os.Stdout); err != nil {
+		log.Fatal(err)
+	}
 }
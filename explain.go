@@ -0,0 +1,48 @@
+package config
+
+import "time"
+
+// Provenance tells where the value of a field of the applied snapshot came
+// from. Found is false when no backend and no default provided the key, so
+// the field kept the value set by Config.NewSnapshot.
+type Provenance struct {
+	Key       string
+	Field     string
+	Found     bool
+	Default   bool
+	Backend   string
+	Source    string
+	Timestamp time.Time
+}
+
+// Explain returns the provenance of every leaf field of the last snapshot
+// applied to the config, in the order of the struct fields.
+func (l *Loader) Explain(c Config) []Provenance {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, w := range l.backendWatcher {
+		if w.config == c {
+			return append([]Provenance(nil), w.provenance...)
+		}
+	}
+	return nil
+}
+
+func explain(fields []*field) []Provenance {
+	var list []Provenance
+	walk(fields, "", "", func(f *field, key, name string) {
+		p := Provenance{
+			Key:     key,
+			Field:   name,
+			Found:   f.found,
+			Default: f.fromDef,
+			Backend: f.backend,
+		}
+		if f.content != nil {
+			p.Source = f.content.Source
+			p.Timestamp = f.content.Timestamp
+		}
+		list = append(list, p)
+	})
+	return list
+}
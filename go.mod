@@ -9,8 +9,8 @@ require (
 	github.com/hashicorp/consul/api v1.33.4
 	github.com/joho/godotenv v1.5.1
 	github.com/json-iterator/go v1.1.12
-	github.com/pkg/errors v0.9.1
 	github.com/stretchr/testify v1.11.1
+	go.uber.org/goleak v1.3.0
 )
 
 require (
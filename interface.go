@@ -1,6 +1,17 @@
 package config
 
+// Config is loaded by a Loader. SetSnapshot is called outside of the
+// Loader's lock, so it may call the Loader's methods reading the configs,
+// like Explain, Dump or Rejected. It must not call the ones building
+// snapshots: Load, Reload and RemoveSource.
 type Config interface {
 	NewSnapshot() interface{}
 	SetSnapshot(interface{}, error)
 }
+
+// Validator is implemented by snapshots, or structs nested in them, that
+// check rules spanning several fields. Validate is called after the values
+// are resolved and before the snapshot is passed to Config.SetSnapshot.
+type Validator interface {
+	Validate() error
+}
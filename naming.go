@@ -0,0 +1,78 @@
+package config
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NamingStrategy derives the key of the struct fields that have no key in
+// their config tag from the name of the field. Untagged embedded structs get
+// no key, their fields are inlined.
+type NamingStrategy int
+
+const (
+	// NamingNone only uses the keys of the tags. This is the default.
+	NamingNone NamingStrategy = iota
+	// NamingExact uses the name of the field, RecallCheckInterval stays
+	// RecallCheckInterval.
+	NamingExact
+	// NamingKebab turns RecallCheckInterval into recall-check-interval, the
+	// format of the keys of the env backend.
+	NamingKebab
+	// NamingSnake turns RecallCheckInterval into recall_check_interval.
+	NamingSnake
+	// NamingLowerCamel turns RecallCheckInterval into recallCheckInterval.
+	NamingLowerCamel
+)
+
+// key returns the key of a field with the given name.
+func (n NamingStrategy) key(name string) string {
+	switch n {
+	case NamingExact:
+		return name
+	case NamingKebab:
+		return strings.ToLower(strings.Join(words(name), "-"))
+	case NamingSnake:
+		return strings.ToLower(strings.Join(words(name), "_"))
+	case NamingLowerCamel:
+		w := words(name)
+		if len(w) == 0 {
+			return ""
+		}
+		w[0] = strings.ToLower(w[0])
+		return strings.Join(w, "")
+	}
+	return ""
+}
+
+// words splits a Go name into its words. Acronyms are kept in one word and
+// digits stay with the word before them, so HTTPServer gives HTTP and Server,
+// Amd2Config gives Amd2 and Config.
+func words(name string) []string {
+	var list []string
+	runes := []rune(name)
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		if runes[i] == '_' {
+			if start < i {
+				list = append(list, string(runes[start:i]))
+			}
+			start = i + 1
+			continue
+		}
+		if i == start || !unicode.IsUpper(runes[i]) {
+			continue
+		}
+		prev := runes[i-1]
+		afterLower := unicode.IsLower(prev) || unicode.IsDigit(prev)
+		acronymEnd := unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+		if afterLower || acronymEnd {
+			list = append(list, string(runes[start:i]))
+			start = i
+		}
+	}
+	if start < len(runes) {
+		list = append(list, string(runes[start:]))
+	}
+	return list
+}
@@ -0,0 +1,76 @@
+package config
+
+// ReloadPolicy decides what happens with a snapshot built on a hot reload
+// when it fails to decode, misses required keys or breaks a validation rule.
+type ReloadPolicy int
+
+const (
+	// ReloadApply passes every snapshot to Config.SetSnapshot together with
+	// its error. This is the default.
+	ReloadApply ReloadPolicy = iota
+	// ReloadKeepLastGood does not call Config.SetSnapshot with a failed
+	// snapshot, so the last good one stays in use. The failed snapshot can
+	// be read with Loader.Rejected. It only protects the last good snapshot
+	// when Config.NewSnapshot returns a new value on every call.
+	ReloadKeepLastGood
+	// ReloadFailFast works like ReloadKeepLastGood, but the first failed
+	// reload also closes the Loader: the backends are not watched anymore
+	// and Reload and AddSource return ErrClosed. The error of that reload
+	// wraps ErrClosed, it is returned by Reload or passed to the OnError
+	// handlers.
+	ReloadFailFast
+)
+
+// Strictness decides how the keys of a backend that no loaded config uses
+// are reported by Loader.CheckUnknownKeys.
+type Strictness int
+
+const (
+	// StrictOff does not check the keys. This is the default.
+	StrictOff Strictness = iota
+	// StrictWarn passes the unknown keys to the OnError handlers.
+	StrictWarn
+	// StrictError returns the unknown keys as errors.
+	StrictError
+)
+
+type Option func(l *Loader)
+
+func WithReloadPolicy(policy ReloadPolicy) Option {
+	return func(l *Loader) {
+		l.reloadPolicy = policy
+	}
+}
+
+// WithNaming sets the strategy deriving the keys of the untagged fields, and
+// of the fields whose tag only has options, like `config:",required"`.
+func WithNaming(naming NamingStrategy) Option {
+	return func(l *Loader) {
+		l.naming = naming
+	}
+}
+
+// WithCaseInsensitiveKeys makes the keys match the data of the backends
+// regardless of their case. Exact matches are still preferred.
+func WithCaseInsensitiveKeys() Option {
+	return func(l *Loader) {
+		l.foldCase = true
+	}
+}
+
+// WithStrict sets the strictness of the named backends, or of every backend
+// without a strictness of its own when no name is given.
+func WithStrict(level Strictness, backends ...string) Option {
+	return func(l *Loader) {
+		if len(backends) == 0 {
+			l.strictDefault = level
+			return
+		}
+		if l.strict == nil {
+			l.strict = make(map[string]Strictness)
+		}
+		for _, name := range backends {
+			l.strict[name] = level
+		}
+	}
+}
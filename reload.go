@@ -0,0 +1,97 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Ak-Army/config/backend"
+)
+
+// Reload reads every backend again and applies the new content to all the
+// loaded configs before returning. Backends that fail to read keep their
+// previous content. The returned error holds the read errors and the errors
+// of the rebuilt snapshots.
+func (l *Loader) Reload(ctx context.Context) error {
+	if l.ctx.Err() != nil {
+		return ErrClosed
+	}
+	l.mu.Lock()
+	sources := append([]backend.Backend(nil), l.backend...)
+	l.mu.Unlock()
+
+	var errs []error
+	contents := make(map[backend.Backend]*backend.Content, len(sources))
+	for _, s := range sources {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		c, err := s.Read()
+		if err != nil {
+			errs = append(errs, &SourceReadError{
+				Backend: s.String(),
+				Err:     err,
+			})
+			continue
+		}
+		contents[s] = c
+	}
+
+	var notifications []notification
+	l.mu.Lock()
+	for s, c := range contents {
+		if _, ok := l.maps[s]; ok {
+			l.maps[s] = c
+		}
+	}
+	for _, w := range l.backendWatcher {
+		changes, err := l.load(w, true)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if len(changes) > 0 {
+			notifications = append(notifications, notification{
+				config:  w.config,
+				changes: changes,
+			})
+		}
+	}
+	l.mu.Unlock()
+	l.apply()
+	l.notify(notifications)
+	if err := l.CheckUnknownKeys(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// ReloadOnSignal calls Reload every time the process receives one of the
+// signals, SIGHUP when none is given. Reload errors are passed to the OnError
+// handlers. It stops listening when the Loader is closed.
+func (l *Loader) ReloadOnSignal(sigs ...os.Signal) {
+	if l.ctx.Err() != nil {
+		return
+	}
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		defer signal.Stop(ch)
+		for {
+			select {
+			case <-l.ctx.Done():
+				return
+			case <-ch:
+				if err := l.Reload(l.ctx); err != nil {
+					l.reportError(err)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,205 @@
+package config
+
+import (
+	stdjson "encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const schemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+type schema map[string]interface{}
+
+// JSONSchema returns the JSON Schema (draft 2020-12) of the data the snapshot
+// is loaded from. The keys, required fields, defaults, descriptions and
+// validation rules come from the config tags, the same way Load reads them.
+func (l *Loader) JSONSchema(snapshot interface{}) ([]byte, error) {
+	ref := reflect.ValueOf(snapshot)
+	if !ref.IsValid() || ref.Kind() != reflect.Ptr || ref.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("provided target must be a pointer to struct")
+	}
+	l.mu.Lock()
+	s := l.objectSchema(l.parseStruct(ref.Elem()))
+	l.mu.Unlock()
+	s["$schema"] = schemaDraft
+	if name := ref.Elem().Type().Name(); name != "" {
+		s["title"] = name
+	}
+	return stdjson.MarshalIndent(s, "", "  ")
+}
+
+func (l *Loader) objectSchema(fields []*field) schema {
+	s := schema{"type": "object"}
+	l.addProperties(s, fields)
+	return s
+}
+
+// addProperties adds the fields to the properties of the object schema.
+// Dotted keys are added as nested objects.
+func (l *Loader) addProperties(s schema, fields []*field) {
+	for _, f := range fields {
+		if f.inline {
+			l.addProperties(s, f.subFields)
+			continue
+		}
+		if f.key == "" {
+			continue
+		}
+		parent := s
+		parts := strings.Split(f.key, ".")
+		for _, part := range parts[:len(parts)-1] {
+			child, ok := properties(parent)[part].(schema)
+			if !ok {
+				child = schema{"type": "object"}
+				properties(parent)[part] = child
+			}
+			parent = child
+		}
+		name := parts[len(parts)-1]
+		properties(parent)[name] = l.fieldSchema(f)
+		if f.required {
+			required, _ := parent["required"].([]string)
+			parent["required"] = append(required, name)
+		}
+	}
+}
+
+func properties(s schema) schema {
+	props, ok := s["properties"].(schema)
+	if !ok {
+		props = schema{}
+		s["properties"] = props
+	}
+	return props
+}
+
+func (l *Loader) fieldSchema(f *field) schema {
+	var s schema
+	if len(f.subFields) != 0 {
+		s = l.objectSchema(f.subFields)
+	} else {
+		s = l.typeSchema(f.value.Type())
+	}
+	if f.desc != "" {
+		s["description"] = f.desc
+	}
+	if f.secret {
+		s["writeOnly"] = true
+	}
+	if f.hasDef {
+		s["default"] = stdjson.RawMessage(defaultJSON(f.def))
+	}
+	for _, r := range f.rules {
+		r.schema(s, f.value.Type())
+	}
+	return s
+}
+
+func (l *Loader) typeSchema(typ reflect.Type) schema {
+	for typ.Kind() == reflect.Ptr {
+		if _, ok := l.decoder(typ); ok {
+			return schema{"type": "string"}
+		}
+		typ = typ.Elem()
+	}
+	switch typ {
+	case durationType:
+		return schema{"type": []string{"string", "number"}}
+	case byteSizeType:
+		return schema{"type": []string{"string", "integer"}}
+	}
+	if _, ok := l.decoder(typ); ok {
+		return schema{"type": "string"}
+	}
+	switch typ.Kind() {
+	case reflect.Bool:
+		return schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return schema{"type": "number"}
+	case reflect.String:
+		return schema{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.Uint8 {
+			// []byte is base64 encoded by the encoders
+			return schema{"type": "string"}
+		}
+		s := schema{
+			"type":  "array",
+			"items": l.typeSchema(typ.Elem()),
+		}
+		if typ.Kind() == reflect.Array {
+			s["maxItems"] = typ.Len()
+		}
+		return s
+	case reflect.Map:
+		return schema{
+			"type":                 "object",
+			"additionalProperties": l.typeSchema(typ.Elem()),
+		}
+	case reflect.Struct:
+		return l.objectSchema(l.parseStruct(reflect.New(typ).Elem()))
+	}
+	return schema{}
+}
+
+// schema adds the keywords checking the rule to the schema of a value of
+// the type. The limits of durations and byte sizes have units, they are not
+// added.
+func (r rule) schema(s schema, typ reflect.Type) {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	// sized values have their length checked, the numbers their value
+	var minimum, maximum string
+	sized := true
+	switch typ.Kind() {
+	case reflect.String:
+		minimum, maximum = "minLength", "maxLength"
+	case reflect.Slice, reflect.Array:
+		minimum, maximum = "minItems", "maxItems"
+	case reflect.Map:
+		minimum, maximum = "minProperties", "maxProperties"
+	default:
+		sized = false
+		if typ != durationType && typ != byteSizeType {
+			minimum, maximum = "minimum", "maximum"
+		}
+	}
+	switch r.name {
+	case "nonempty":
+		if sized {
+			s[minimum] = 1
+		}
+	case "len":
+		if n, err := strconv.Atoi(r.arg); err == nil && sized {
+			s[minimum], s[maximum] = n, n
+		}
+	case "min", "max":
+		keyword := minimum
+		if r.name == "max" {
+			keyword = maximum
+		}
+		if n, err := strconv.ParseFloat(r.arg, 64); err == nil && keyword != "" {
+			s[keyword] = n
+		}
+	case "oneof":
+		var enum []interface{}
+		for _, allowed := range strings.Split(r.arg, "|") {
+			if n, err := strconv.ParseFloat(allowed, 64); err == nil && !sized && minimum != "" {
+				enum = append(enum, n)
+				continue
+			}
+			enum = append(enum, allowed)
+		}
+		s["enum"] = enum
+	case "regex":
+		if typ.Kind() == reflect.String {
+			s["pattern"] = r.arg
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Store holds the last snapshot of a config struct loaded by a Loader. It
+// replaces the hand written Config implementations, the snapshot can be read
+// from any goroutine without locking.
+type Store[T any] struct {
+	loader      *Loader
+	defaults    func() *T
+	current     atomic.Pointer[T]
+	mu          sync.Mutex
+	err         error
+	subscribers []func(old, new *T)
+	closed      bool
+	notifyMu    sync.Mutex
+	last        *T
+}
+
+// binding is the Config the Loader sees for a Store.
+type binding[T any] struct {
+	store *Store[T]
+}
+
+func (b binding[T]) NewSnapshot() interface{} {
+	if b.store.defaults != nil {
+		return b.store.defaults()
+	}
+	return new(T)
+}
+
+func (b binding[T]) SetSnapshot(snapshot interface{}, err error) {
+	b.store.mu.Lock()
+	defer b.store.mu.Unlock()
+	if b.store.closed {
+		return
+	}
+	b.store.current.Store(snapshot.(*T))
+	b.store.err = err
+}
+
+// Bind loads the config struct T with the loader and keeps it up to date on
+// reloads until the store is closed. The defaults function returns the
+// snapshot the values are resolved into, it can be nil.
+func Bind[T any](l *Loader, defaults func() *T) (*Store[T], error) {
+	s := &Store[T]{
+		loader:   l,
+		defaults: defaults,
+	}
+	b := binding[T]{store: s}
+	if err := l.Load(b); err != nil {
+		return nil, err
+	}
+	if err := s.Err(); err != nil {
+		l.Unload(b)
+		return nil, err
+	}
+	// the handler is registered only for the stores that were bound, the
+	// loader has no way to remove it, Close turns it into a no-op
+	s.notifyMu.Lock()
+	l.OnChange(func(c Config, _ []Change) {
+		if b, ok := c.(binding[T]); ok && b.store == s {
+			s.changed()
+		}
+	})
+	s.last = s.current.Load()
+	s.notifyMu.Unlock()
+	return s, nil
+}
+
+// Get returns the current snapshot. It is shared by every caller, it must
+// not be modified.
+func (s *Store[T]) Get() *T {
+	return s.current.Load()
+}
+
+// Err returns the error the current snapshot was loaded with.
+func (s *Store[T]) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Subscribe registers a function called with the previous and the new
+// snapshot after a reload changed the config. The function is called outside
+// of the Loader's lock.
+func (s *Store[T]) Subscribe(fn func(old, new *T)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribers = append(s.subscribers, fn)
+}
+
+// Close stops keeping the store up to date, Get keeps returning the last
+// snapshot and the subscribers are not called anymore. It returns
+// ErrNotLoaded when the store is already closed.
+func (s *Store[T]) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	return s.loader.Unload(binding[T]{store: s})
+}
+
+// Config returns the Config the store is registered with, to be passed to
+// the methods of the Loader like Explain or Dump.
+func (s *Store[T]) Config() Config {
+	return binding[T]{store: s}
+}
+
+func (s *Store[T]) changed() {
+	s.notifyMu.Lock()
+	defer s.notifyMu.Unlock()
+	s.mu.Lock()
+	subscribers, closed := s.subscribers, s.closed
+	s.mu.Unlock()
+	if closed {
+		return
+	}
+	old, current := s.last, s.current.Load()
+	s.last = current
+	for _, fn := range subscribers {
+		fn(old, current)
+	}
+}
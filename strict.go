@@ -0,0 +1,197 @@
+package config
+
+import (
+	stdjson "encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/Ak-Army/config/encoder"
+)
+
+// keyKind tells how the keys below a known key are checked.
+type keyKind int
+
+const (
+	// keyNode is a struct, every key below it must be known.
+	keyNode keyKind = iota
+	// keyLeaf is a value, the keys below it are not checked.
+	keyLeaf
+	// keyList is a list, its items are checked against the key followed by
+	// "[]".
+	keyList
+	// keyMap is a map, its entries are checked against the key followed by
+	// ".*".
+	keyMap
+)
+
+// CheckUnknownKeys looks for the keys of the strict backends that none of
+// the loaded configs use. The keys of the backends with StrictWarn are passed
+// to the OnError handlers, the ones with StrictError are returned. It should
+// be called once every config is loaded, the reloads check the keys again.
+func (l *Loader) CheckUnknownKeys() error {
+	l.mu.Lock()
+	warnings, errs := l.unknownKeys()
+	l.mu.Unlock()
+	for _, err := range warnings {
+		l.reportError(err)
+	}
+	return errors.Join(errs...)
+}
+
+func (l *Loader) strictness(name string) Strictness {
+	if level, ok := l.strict[name]; ok {
+		return level
+	}
+	return l.strictDefault
+}
+
+func (l *Loader) unknownKeys() (warnings, errs []error) {
+	if l.strictDefault == StrictOff && len(l.strict) == 0 {
+		return nil, nil
+	}
+	known := make(map[string]keyKind)
+	for _, w := range l.backendWatcher {
+		if w.snapshot == nil {
+			continue
+		}
+		fields := l.parseStruct(reflect.ValueOf(w.snapshot).Elem())
+		l.knownKeys(fields, "", known)
+	}
+	for _, s := range l.sources() {
+		level := l.strictness(s.String())
+		if level == StrictOff {
+			continue
+		}
+		content := l.maps[s]
+		unknown := l.checkKeys(content.Data, content.Encoder, "", "", known)
+		sort.Strings(unknown)
+		for _, key := range unknown {
+			err := &UnknownKeyError{
+				Backend: s.String(),
+				Key:     key,
+			}
+			if level == StrictWarn {
+				warnings = append(warnings, err)
+			} else {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return warnings, errs
+}
+
+// knownKeys collects the key paths the fields use. The parents of dotted
+// keys are known as structs.
+func (l *Loader) knownKeys(fields []*field, prefix string, known map[string]keyKind) {
+	for _, f := range fields {
+		key := prefix
+		if !f.inline {
+			key = joinKey(prefix, f.key)
+		}
+		if l.foldCase {
+			key = strings.ToLower(key)
+		}
+		for i := range key {
+			if key[i] == '.' {
+				if _, ok := known[key[:i]]; !ok {
+					known[key[:i]] = keyNode
+				}
+			}
+		}
+		switch {
+		case f.isList || f.isMap:
+			l.knownElem(f.value.Type(), key, known)
+		case len(f.subFields) != 0:
+			if _, ok := known[key]; !ok && !f.inline {
+				known[key] = keyNode
+			}
+			l.knownKeys(f.subFields, key, known)
+		default:
+			known[key] = keyLeaf
+		}
+	}
+}
+
+// knownElem collects the key paths of a list or a map of the type. Lists and
+// maps of values are leaves, the items and entries of the others are known by
+// their key followed by "[]" or ".*".
+func (l *Loader) knownElem(typ reflect.Type, key string, known map[string]keyKind) {
+	if !l.composite(typ) {
+		known[key] = keyLeaf
+		return
+	}
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.Struct:
+		if _, ok := known[key]; !ok {
+			known[key] = keyNode
+		}
+		l.knownKeys(l.parseStruct(reflect.New(typ).Elem()), key, known)
+	case reflect.Slice, reflect.Array:
+		known[key] = keyList
+		l.knownElem(typ.Elem(), key+"[]", known)
+	case reflect.Map:
+		known[key] = keyMap
+		l.knownElem(typ.Elem(), key+".*", known)
+	}
+}
+
+// checkKeys returns the key paths of the data that are not known. The
+// pattern is the prefix as it is stored in known, with "[]" and "*" in
+// place of the indexes of the items and the names of the entries.
+func (l *Loader) checkKeys(data encoder.Data, enc encoder.Encoder, prefix, pattern string, known map[string]keyKind) []string {
+	var unknown []string
+	for k, v := range data {
+		key, match := joinKey(prefix, k), joinKey(pattern, k)
+		if l.foldCase {
+			match = strings.ToLower(match)
+		}
+		if _, ok := known[match]; !ok {
+			unknown = append(unknown, key)
+			continue
+		}
+		unknown = append(unknown, l.checkValue(v, enc, key, match, known)...)
+	}
+	return unknown
+}
+
+// checkValue returns the unknown key paths below the value of a known key.
+// Values that do not fit the type of the key fail to decode anyway, they
+// are not checked.
+func (l *Loader) checkValue(v interface{}, enc encoder.Encoder, key, match string, known map[string]keyKind) []string {
+	switch known[match] {
+	case keyNode:
+		sub, err := enc.DecodeData(v)
+		if err != nil {
+			return nil
+		}
+		return l.checkKeys(sub, enc, key, match, known)
+	case keyList:
+		var items []stdjson.RawMessage
+		if err := enc.Decode(v, &items); err != nil {
+			return nil
+		}
+		var unknown []string
+		for i, item := range items {
+			itemKey := fmt.Sprintf("%s[%d]", key, i)
+			unknown = append(unknown, l.checkValue(item, enc, itemKey, match+"[]", known)...)
+		}
+		return unknown
+	case keyMap:
+		entries, err := enc.DecodeData(v)
+		if err != nil {
+			return nil
+		}
+		var unknown []string
+		for name, entry := range entries {
+			unknown = append(unknown, l.checkValue(entry, enc, joinKey(key, name), match+".*", known)...)
+		}
+		return unknown
+	}
+	return nil
+}
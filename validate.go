@@ -0,0 +1,335 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	byteSizeType = reflect.TypeOf(ByteSize(0))
+)
+
+// ValidationError describes a value that breaks one of the rules given in
+// the config tag of its field.
+type ValidationError struct {
+	Key     string
+	Field   string
+	Backend string
+	Rule    string
+	Value   interface{}
+	Err     error
+}
+
+func (e *ValidationError) Error() string {
+	backend := e.Backend
+	if backend == "" {
+		backend = "snapshot"
+	}
+	return fmt.Sprintf("invalid value '%v' for key '%s' (field '%s', backend '%s'): %s",
+		e.Value, e.Key, e.Field, backend, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationErrors holds every rule violation found in a snapshot.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("validation errors: %s", strings.Join(msgs, "\n"))
+}
+
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+type rule struct {
+	name string
+	arg  string
+}
+
+func parseRule(opt string) (rule, bool) {
+	if opt == "nonempty" {
+		return rule{name: opt}, true
+	}
+	idx := strings.Index(opt, "=")
+	if idx == -1 {
+		return rule{}, false
+	}
+	switch name := opt[:idx]; name {
+	case "min", "max", "len", "oneof", "regex":
+		return rule{name: name, arg: opt[idx+1:]}, true
+	}
+	return rule{}, false
+}
+
+func (r rule) String() string {
+	if r.arg == "" {
+		return r.name
+	}
+	return r.name + "=" + r.arg
+}
+
+func (l *Loader) validate(fields []*field) error {
+	errs := validateFields(fields, "", "")
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validateFields(fields []*field, prefix, namePrefix string) ValidationErrors {
+	var errs ValidationErrors
+	for _, f := range fields {
+		key, name := prefix, namePrefix
+		if !f.inline {
+			key = joinKey(prefix, f.key)
+			name = joinKey(namePrefix, f.name)
+		}
+		if f.required && !f.found {
+			// reported as a missing key
+			continue
+		}
+		for _, r := range f.rules {
+			if err := r.check(f.value); err != nil {
+				errs = append(errs, &ValidationError{
+					Key:     key,
+					Field:   name,
+					Backend: f.backend,
+					Rule:    r.String(),
+					Value:   printable(f.value),
+					Err:     err,
+				})
+			}
+		}
+		switch {
+		case f.isList:
+			errs = append(errs, validateFields(f.items, key, name)...)
+		case f.isMap:
+			errs = append(errs, validateFields(f.entries, key, name)...)
+		case len(f.subFields) != 0:
+			if f.value.Kind() == reflect.Ptr && f.value.IsNil() {
+				continue
+			}
+			errs = append(errs, validateFields(f.subFields, key, name)...)
+		}
+	}
+	return errs
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" || strings.HasPrefix(key, "[") {
+		return prefix + key
+	}
+	return prefix + "." + key
+}
+
+func printable(v reflect.Value) interface{} {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	return v.Interface()
+}
+
+func (r rule) check(v reflect.Value) error {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			if r.name == "nonempty" {
+				return fmt.Errorf("must not be empty")
+			}
+			return nil
+		}
+		v = v.Elem()
+	}
+	switch r.name {
+	case "nonempty":
+		if v.IsZero() || hasLen(v) && v.Len() == 0 {
+			return fmt.Errorf("must not be empty")
+		}
+	case "len":
+		n, err := strconv.Atoi(r.arg)
+		if err != nil {
+			return fmt.Errorf("invalid rule %s: %s", r, err)
+		}
+		if !hasLen(v) {
+			return fmt.Errorf("rule %s is not supported for %s", r, v.Type())
+		}
+		if v.Len() != n {
+			return fmt.Errorf("length must be %d", n)
+		}
+	case "min", "max":
+		cmp, err := compare(v, r.arg)
+		if err != nil {
+			return fmt.Errorf("invalid rule %s: %s", r, err)
+		}
+		if r.name == "min" && cmp < 0 {
+			return fmt.Errorf("must be at least %s", r.arg)
+		}
+		if r.name == "max" && cmp > 0 {
+			return fmt.Errorf("must be at most %s", r.arg)
+		}
+	case "oneof":
+		value := fmt.Sprint(v.Interface())
+		for _, allowed := range strings.Split(r.arg, "|") {
+			if value == allowed {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %s", strings.Replace(r.arg, "|", ", ", -1))
+	case "regex":
+		re, err := regexp.Compile(r.arg)
+		if err != nil {
+			return fmt.Errorf("invalid rule %s: %s", r, err)
+		}
+		if v.Kind() != reflect.String {
+			return fmt.Errorf("rule %s is not supported for %s", r, v.Type())
+		}
+		if !re.MatchString(v.String()) {
+			return fmt.Errorf("must match %s", r.arg)
+		}
+	}
+	return nil
+}
+
+func hasLen(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return true
+	}
+	return false
+}
+
+// compare returns -1, 0 or 1 as the value is less than, equal to or greater
+// than the limit. Strings, slices and maps are compared by their length.
+func compare(v reflect.Value, limit string) (int, error) {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(limit)
+		if err != nil {
+			n, nerr := strconv.ParseInt(limit, 10, 64)
+			if nerr != nil {
+				return 0, err
+			}
+			d = time.Duration(n)
+		}
+		return compareFloat(float64(v.Int()), float64(d)), nil
+	}
+	if v.Type() == byteSizeType {
+		b, err := ParseByteSize(limit)
+		if err != nil {
+			return 0, err
+		}
+		return compareFloat(float64(v.Uint()), float64(b)), nil
+	}
+	var value float64
+	switch {
+	case hasLen(v):
+		value = float64(v.Len())
+	case v.CanInt():
+		value = float64(v.Int())
+	case v.CanUint():
+		value = float64(v.Uint())
+	case v.CanFloat():
+		value = v.Float()
+	default:
+		return 0, fmt.Errorf("not supported for %s", v.Type())
+	}
+	n, err := strconv.ParseFloat(limit, 64)
+	if err != nil {
+		return 0, err
+	}
+	return compareFloat(value, n), nil
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// ValidatorError is returned when the Validate method of the snapshot or of
+// a struct nested in it fails. Path is the Go field path of that struct.
+type ValidatorError struct {
+	Path string
+	Err  error
+}
+
+func (e *ValidatorError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("validation failed: %s", e.Err)
+	}
+	return fmt.Sprintf("validation failed for '%s': %s", e.Path, e.Err)
+}
+
+func (e *ValidatorError) Unwrap() error {
+	return e.Err
+}
+
+// callValidators walks the value depth first, so nested structs are
+// validated before the structs containing them.
+func callValidators(v reflect.Value, path string) error {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return callValidators(v.Elem(), path)
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			if t.Field(i).PkgPath != "" {
+				continue
+			}
+			if err := callValidators(v.Field(i), joinKey(path, t.Field(i).Name)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := callValidators(v.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := callValidators(iter.Value(), fmt.Sprintf("%s[%v]", path, iter.Key())); err != nil {
+				return err
+			}
+		}
+	}
+	if !v.CanInterface() {
+		return nil
+	}
+	validator, ok := v.Interface().(Validator)
+	if !ok && v.CanAddr() {
+		validator, ok = v.Addr().Interface().(Validator)
+	}
+	if !ok {
+		return nil
+	}
+	if err := validator.Validate(); err != nil {
+		return &ValidatorError{Path: path, Err: err}
+	}
+	return nil
+}